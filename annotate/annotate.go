@@ -0,0 +1,44 @@
+// Package annotate renders excerpts of source text with a caret marking a
+// location, for use in error messages and reports.
+package annotate
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+const lineTerminators = "\r\n\u2028\u2029"
+
+// Line returns the line of source containing offset, followed by a second line
+// with a caret under offset and then message.
+func Line(source string, offset int, message string) string {
+	offset = min(max(offset, 0), len(source))
+
+	start := 0
+	if i := strings.LastIndexAny(source[:offset], lineTerminators); i >= 0 {
+		_, size := utf8.DecodeRuneInString(source[i:])
+		start = i + size
+	}
+	end := len(source)
+	if i := strings.IndexAny(source[offset:], lineTerminators); i >= 0 {
+		end = offset + i
+	}
+
+	// Mirror tabs in the padding so the caret lines up regardless of tab width
+	var b strings.Builder
+	b.WriteString(source[start:end])
+	b.WriteByte('\n')
+	for _, ch := range source[start:offset] {
+		if ch == '\t' {
+			b.WriteRune('\t')
+		} else {
+			b.WriteRune(' ')
+		}
+	}
+	b.WriteByte('^')
+	if message != "" {
+		b.WriteByte(' ')
+		b.WriteString(message)
+	}
+	return b.String()
+}
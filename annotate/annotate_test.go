@@ -0,0 +1,24 @@
+package annotate
+
+import "testing"
+
+func TestLine(t *testing.T) {
+	tests := []struct {
+		source  string
+		offset  int
+		message string
+		want    string
+	}{
+		{"abc", 1, "here", "abc\n ^ here"},
+		{"one\ntwo\nthree", 5, "", "two\n ^"},
+		{"a\r\n\tb c", 6, "x", "\tb c\n\t  ^ x"},
+		{"end", 3, "eof", "end\n   ^ eof"},
+		{"", 0, "empty", "\n^ empty"},
+	}
+
+	for _, tt := range tests {
+		if got := Line(tt.source, tt.offset, tt.message); got != tt.want {
+			t.Errorf("Line(%q, %d): expected %q, got %q", tt.source, tt.offset, tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,229 @@
+package ast
+
+import (
+	"fmt"
+
+	"github.com/Roundaround/json5-go/path"
+)
+
+// An ApplyFunc is invoked by Apply for each node n, before and/or after the
+// node's children, using a Cursor describing the current node and providing
+// operations on it.
+//
+// The return value of ApplyFunc controls the syntax tree traversal.
+// See Apply for details.
+type ApplyFunc func(*Cursor) bool
+
+// Apply traverses a syntax tree recursively, starting with root, and calling
+// pre and post for each node as described below. Apply returns the syntax tree,
+// possibly modified.
+//
+// If pre is not nil, it is called for each node before the node's children
+// are traversed (pre-order). If pre returns false, no children are traversed,
+// and post is not called for that node.
+//
+// If post is not nil, and a prior call of pre didn't return false, post is
+// called for each node after its children are traversed (post-order). If post
+// returns false, traversal is terminated and Apply returns immediately.
+//
+// Object members and array elements are traversed in order. Nodes inserted
+// with the Cursor's insert methods are not traversed, while the children of a
+// node set with Replace during pre are.
+func Apply(root Node, pre, post ApplyFunc) (result Node) {
+	a := &application{pre: pre, post: post, root: root, path: path.Must()}
+	a.apply(nil, -1, root)
+	return a.root
+}
+
+// A Cursor describes a node encountered during Apply. Information about the
+// node and its parent is available from the Node, Parent, Index, Key and Path
+// methods.
+type Cursor struct {
+	app     *application
+	parent  Node
+	index   int
+	node    Node
+	deleted bool
+}
+
+// Node returns the current node.
+func (c *Cursor) Node() Node {
+	return c.node
+}
+
+// Parent returns the parent of the current node, or nil for the root.
+func (c *Cursor) Parent() Node {
+	return c.parent
+}
+
+// Index returns the index of the current node within its parent's members or
+// elements, or -1 for the root.
+func (c *Cursor) Index() int {
+	return c.index
+}
+
+// Key returns the key of the current node if its parent is an object.
+func (c *Cursor) Key() (string, bool) {
+	obj, ok := c.parent.(*ObjectNode)
+	if !ok {
+		return "", false
+	}
+	return obj.members[c.index].key, true
+}
+
+// Path returns the path from the root to the current node.
+func (c *Cursor) Path() *path.Path {
+	return c.app.path.Clone()
+}
+
+// Replace replaces the current node with n.
+func (c *Cursor) Replace(n Node) {
+	switch parent := c.parent.(type) {
+	case nil:
+		c.app.root = n
+	case *ObjectNode:
+		m := parent.members[c.index]
+		detach(m.value)
+		m.value = n
+		attach(n, parent, m.segment)
+	case *ArrayNode:
+		parent.Replace(c.index, n)
+	}
+	c.node = n
+}
+
+// Delete deletes the current node from its parent. It panics if the current
+// node is the root. The children of a deleted node are not traversed.
+func (c *Cursor) Delete() {
+	switch parent := c.parent.(type) {
+	case nil:
+		panic("ast: Delete called on the root node")
+	case *ObjectNode:
+		parent.removeAt(c.index)
+	case *ArrayNode:
+		parent.Remove(c.index)
+	}
+	c.app.iter.step--
+	c.deleted = true
+}
+
+// InsertBefore inserts n before the current array element. It panics if the
+// parent of the current node is not an array.
+func (c *Cursor) InsertBefore(n Node) {
+	c.array("InsertBefore").Insert(c.index, n)
+	c.index++
+	c.app.iter.index++
+	c.app.path.Pop()
+	c.app.path.Index(c.index)
+}
+
+// InsertAfter inserts n after the current array element. It panics if the
+// parent of the current node is not an array.
+func (c *Cursor) InsertAfter(n Node) {
+	c.array("InsertAfter").Insert(c.index+1, n)
+	c.app.iter.step++
+}
+
+// InsertMemberBefore inserts a member before the current object member. It
+// panics if the parent of the current node is not an object, and fails if key
+// already exists.
+func (c *Cursor) InsertMemberBefore(key string, n Node) error {
+	if err := c.object("InsertMemberBefore").InsertAt(c.index, key, n); err != nil {
+		return err
+	}
+	c.index++
+	c.app.iter.index++
+	return nil
+}
+
+// InsertMemberAfter inserts a member after the current object member. It
+// panics if the parent of the current node is not an object, and fails if key
+// already exists.
+func (c *Cursor) InsertMemberAfter(key string, n Node) error {
+	if err := c.object("InsertMemberAfter").InsertAt(c.index+1, key, n); err != nil {
+		return err
+	}
+	c.app.iter.step++
+	return nil
+}
+
+func (c *Cursor) array(method string) *ArrayNode {
+	if parent, ok := c.parent.(*ArrayNode); ok {
+		return parent
+	}
+	panic(fmt.Sprintf("ast: %s called on a node whose parent is not an array", method))
+}
+
+func (c *Cursor) object(method string) *ObjectNode {
+	if parent, ok := c.parent.(*ObjectNode); ok {
+		return parent
+	}
+	panic(fmt.Sprintf("ast: %s called on a node whose parent is not an object", method))
+}
+
+type application struct {
+	pre, post ApplyFunc
+	root      Node
+	path      *path.Path
+	iter      iterator
+	aborted   bool
+}
+
+// iterator tracks the position within the current parent's children. Cursor
+// operations adjust it so that inserted nodes are skipped and deleted nodes
+// don't cause a sibling to be missed.
+type iterator struct {
+	index, step int
+}
+
+func (a *application) apply(parent Node, index int, n Node) {
+	c := &Cursor{app: a, parent: parent, index: index, node: n}
+
+	if a.pre != nil && !a.pre(c) {
+		return
+	}
+	if c.deleted {
+		return
+	}
+
+	a.applyChildren(c.node)
+	if a.aborted {
+		return
+	}
+
+	if a.post != nil && !a.post(c) {
+		a.aborted = true
+	}
+}
+
+func (a *application) applyChildren(parent Node) {
+	saved := a.iter
+	a.iter.index = 0
+	for !a.aborted {
+		child, ok := childAt(parent, a.iter.index)
+		if !ok {
+			break
+		}
+
+		a.iter.step = 1
+		a.path.Append(child.Segment())
+		a.apply(parent, a.iter.index, child)
+		a.path.Pop()
+		a.iter.index += a.iter.step
+	}
+	a.iter = saved
+}
+
+func childAt(parent Node, index int) (Node, bool) {
+	switch n := parent.(type) {
+	case *ObjectNode:
+		if index < len(n.members) {
+			return n.members[index].value, true
+		}
+	case *ArrayNode:
+		if index < len(n.values) {
+			return n.values[index], true
+		}
+	}
+	return nil, false
+}
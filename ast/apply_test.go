@@ -0,0 +1,168 @@
+package ast
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/Roundaround/json5-go/path"
+)
+
+func TestApply(t *testing.T) {
+	source := `{
+  user: 'admin',
+  password: 'hunter2',
+  limits: [0x10, null, 0x20, null],
+  nested: { token: 'abc', keep: true },
+}`
+
+	root, err := Parse(source)
+	if err != nil {
+		t.Fatalf("returned unexpected error %v", err)
+	}
+
+	visited := make([]string, 0)
+	result := Apply(root, func(c *Cursor) bool {
+		visited = append(visited, c.Path().String())
+
+		if key, ok := c.Key(); ok && (key == "password" || key == "token") {
+			c.Replace(NewString("<redacted>"))
+		}
+
+		switch n := c.Node().(type) {
+		case *NullNode:
+			c.Delete()
+		case *NumberNode:
+			if n.IsHex() {
+				i, _ := n.Int64()
+				c.Replace(NewNumber(i))
+			}
+		}
+		return true
+	}, nil)
+
+	if result != root {
+		t.Fatalf("expected root to be unchanged")
+	}
+
+	want := []string{
+		"",
+		"user",
+		"password",
+		"limits",
+		"limits[0]",
+		"limits[1]",
+		"limits[1]",
+		"limits[2]",
+		"nested",
+		"nested.token",
+		"nested.keep",
+	}
+	if !slices.Equal(visited, want) {
+		t.Errorf("expected visits %q, got %q", want, visited)
+	}
+
+	obj := root.(*ObjectNode)
+	if password, _ := obj.Value("password"); password.(*StringNode).Value() != "<redacted>" {
+		t.Errorf("expected password to be redacted")
+	}
+	nested, _ := obj.Value("nested")
+	if token, _ := nested.(*ObjectNode).Value("token"); token.(*StringNode).Value() != "<redacted>" {
+		t.Errorf("expected token to be redacted")
+	}
+
+	limits, _ := obj.Value("limits")
+	raws := make([]string, 0)
+	for i, value := range limits.(*ArrayNode).Values() {
+		raws = append(raws, value.(*NumberNode).String())
+		if segment := value.Segment(); segment != path.Index(i) {
+			t.Errorf("expected segment [%d], got %s", i, segment.String())
+		}
+	}
+	if !slices.Equal(raws, []string{"16", "32"}) {
+		t.Errorf("expected normalized limits [16 32], got %v", raws)
+	}
+}
+
+func TestApply_Insert(t *testing.T) {
+	root, err := Parse("{a: [1, 2], b: 3}")
+	if err != nil {
+		t.Fatalf("returned unexpected error %v", err)
+	}
+
+	visited := 0
+	Apply(root, func(c *Cursor) bool {
+		visited++
+		if n, ok := c.Node().(*NumberNode); ok && c.Parent().Kind() == ARRAY {
+			c.InsertBefore(NewString("before " + n.String()))
+			c.InsertAfter(NewString("after " + n.String()))
+			if !c.Path().Equals(n.Path()) {
+				t.Errorf("expected cursor path %q after inserting, got %q", n.Path(), c.Path())
+			}
+		}
+		if key, ok := c.Key(); ok && key == "b" {
+			if err := c.InsertMemberBefore("x", NewNull()); err != nil {
+				t.Errorf("returned unexpected error %v", err)
+			}
+			if err := c.InsertMemberAfter("y", NewNull()); err != nil {
+				t.Errorf("returned unexpected error %v", err)
+			}
+			if err := c.InsertMemberAfter("a", NewNull()); err == nil {
+				t.Errorf("expected error when inserting an existing key")
+			}
+		}
+		return true
+	}, nil)
+
+	// Inserted nodes are not visited
+	if visited != 5 {
+		t.Errorf("expected 5 visits, got %d", visited)
+	}
+
+	obj := root.(*ObjectNode)
+	if keys := obj.Keys(); !slices.Equal(keys, []string{"a", "x", "b", "y"}) {
+		t.Errorf("expected keys [a x b y], got %v", keys)
+	}
+
+	a, _ := obj.Value("a")
+	values := make([]string, 0)
+	for _, value := range a.(*ArrayNode).Values() {
+		switch v := value.(type) {
+		case *StringNode:
+			values = append(values, v.Value())
+		case *NumberNode:
+			values = append(values, v.String())
+		}
+	}
+	want := []string{"before 1", "1", "after 1", "before 2", "2", "after 2"}
+	if !slices.Equal(values, want) {
+		t.Errorf("expected %q, got %q", want, values)
+	}
+}
+
+func TestApply_PostAndRoot(t *testing.T) {
+	root, err := Parse("[[1, 2], [3, 4]]")
+	if err != nil {
+		t.Fatalf("returned unexpected error %v", err)
+	}
+
+	order := make([]string, 0)
+	Apply(root, nil, func(c *Cursor) bool {
+		order = append(order, c.Path().String())
+		return c.Path().String() != "[0]"
+	})
+
+	want := []string{"[0][0]", "[0][1]", "[0]"}
+	if !slices.Equal(order, want) {
+		t.Errorf("expected post-order %q before aborting, got %q", want, order)
+	}
+
+	result := Apply(root, func(c *Cursor) bool {
+		if c.Parent() == nil {
+			c.Replace(NewNull())
+		}
+		return true
+	}, nil)
+	if result.Kind() != NULL {
+		t.Errorf("expected root to be replaced with null, got %s", result.Kind())
+	}
+}
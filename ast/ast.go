@@ -1,10 +1,13 @@
 package ast
 
 import (
-	"strconv"
+	"fmt"
+	"iter"
+	"math"
 	"strings"
 
 	"github.com/Roundaround/json5-go/path"
+	"github.com/Roundaround/json5-go/token"
 )
 
 type Kind int
@@ -52,14 +55,28 @@ type Node interface {
 	Offset() int
 	Line() int
 	Column() int
+	Start() token.Position
+	End() token.Position
+	Span() token.Span
+	Source() string
 	Segment() path.Segment
+	Parent() Node
+	Path() *path.Path
+	LeadingComments() []*CommentNode
+	TrailingComments() []*CommentNode
 }
 
+// Position records where a node sits, both in the source and within the tree.
+// It is embedded by every node type.
 type Position struct {
 	offset  int
 	line    int
 	column  int
+	end     token.Position
+	source  string
 	segment path.Segment
+	parent  Node
+	comments
 }
 
 func (p *Position) Offset() int {
@@ -74,12 +91,161 @@ func (p *Position) Column() int {
 	return p.column
 }
 
+func (p *Position) Start() token.Position {
+	return token.Position{Source: p.source, Offset: p.offset, Line: p.line, Column: p.column}
+}
+
+func (p *Position) End() token.Position {
+	return p.end
+}
+
+func (p *Position) Span() token.Span {
+	return token.Span{Start: p.Start(), End: p.end}
+}
+
+// Source returns the name of the document the node was parsed from, as given
+// to SourceName.
+func (p *Position) Source() string {
+	return p.source
+}
+
 func (p *Position) Segment() path.Segment {
 	return p.segment
 }
 
+func (p *Position) Parent() Node {
+	return p.parent
+}
+
+// Path returns the full path from the root of the tree to this node.
+func (p *Position) Path() *path.Path {
+	if p.parent == nil {
+		return path.Must()
+	}
+	result := p.parent.Path()
+	result.Append(p.segment)
+	return result
+}
+
+// comments holds the comments attached to a node. Leading comments precede
+// the node, while trailing comments follow it on the same line.
+type comments struct {
+	leading  []*CommentNode
+	trailing []*CommentNode
+}
+
+func (c *comments) LeadingComments() []*CommentNode {
+	return c.leading
+}
+
+func (c *comments) TrailingComments() []*CommentNode {
+	return c.trailing
+}
+
+// AddLeadingComments attaches comments before node.
+func AddLeadingComments(node Node, comments ...*CommentNode) {
+	if p := positionOf(node); p != nil {
+		for _, c := range comments {
+			c.parent = node
+		}
+		p.leading = append(p.leading, comments...)
+	}
+}
+
+// AddTrailingComments attaches comments after node.
+func AddTrailingComments(node Node, comments ...*CommentNode) {
+	if p := positionOf(node); p != nil {
+		for _, c := range comments {
+			c.parent = node
+		}
+		p.trailing = append(p.trailing, comments...)
+	}
+}
+
+func (p *Position) position() *Position {
+	return p
+}
+
+func positionOf(node Node) *Position {
+	if n, ok := node.(interface{ position() *Position }); ok {
+		return n.position()
+	}
+	return nil
+}
+
+// attach records the parent of a node and the segment it occupies within
+// that parent.
+func attach(node Node, parent Node, segment path.Segment) {
+	if p := positionOf(node); p != nil {
+		p.parent = parent
+		p.segment = segment
+	}
+}
+
+// Member is a single key/value pair within an object. Its position is the
+// position of the key.
+type Member struct {
+	key   string
+	value Node
+	Position
+}
+
+func (m *Member) Key() string {
+	return m.key
+}
+
+func (m *Member) Value() Node {
+	return m.value
+}
+
+type DuplicateKeyPolicy int
+
+const (
+	// LastWins keeps the value of the last occurrence of a key, in the position
+	// of the first occurrence.
+	LastWins DuplicateKeyPolicy = iota
+	// FirstWins keeps the first occurrence of a key.
+	FirstWins
+	// ErrorOnDuplicate rejects objects containing duplicate keys.
+	ErrorOnDuplicate
+	// CollectDuplicates keeps every occurrence of a key as a separate member.
+	// Lookups by key resolve to the last occurrence.
+	CollectDuplicates
+)
+
+func (p DuplicateKeyPolicy) String() string {
+	switch p {
+	case LastWins:
+		return "Last Wins"
+	case FirstWins:
+		return "First Wins"
+	case ErrorOnDuplicate:
+		return "Error"
+	case CollectDuplicates:
+		return "Collect"
+	default:
+		return "Unknown"
+	}
+}
+
+type DuplicateKeyError struct {
+	Key       string
+	First     *Member
+	Duplicate *Member
+}
+
+func (e *DuplicateKeyError) Error() string {
+	return fmt.Sprintf("duplicate key %q (first defined at ln %d, col %d)", e.Key, e.First.Line(), e.First.Column())
+}
+
+// ObjectNode holds its members in source order. Keys that occur more than once
+// are resolved according to a DuplicateKeyPolicy, and every occurrence that
+// was shadowed or repeated is available from Duplicates.
 type ObjectNode struct {
-	values map[string]Node
+	members    []*Member
+	index      map[string]int
+	duplicates []*Member
+	dangling   []*CommentNode
 	Position
 }
 
@@ -88,20 +254,109 @@ func (n *ObjectNode) Kind() Kind {
 }
 
 func (n *ObjectNode) Len() int {
-	return len(n.values)
+	return len(n.members)
+}
+
+func (n *ObjectNode) Keys() []string {
+	keys := make([]string, 0, len(n.index))
+	seen := make(map[string]bool, len(n.index))
+	for _, m := range n.members {
+		if !seen[m.key] {
+			seen[m.key] = true
+			keys = append(keys, m.key)
+		}
+	}
+	return keys
+}
+
+func (n *ObjectNode) Members() []*Member {
+	return n.members
+}
+
+func (n *ObjectNode) Member(key string) (*Member, bool) {
+	i, ok := n.index[key]
+	if !ok {
+		return nil, false
+	}
+	return n.members[i], true
+}
+
+// All returns an iterator over the object's keys and values in source order.
+func (n *ObjectNode) All() iter.Seq2[string, Node] {
+	return func(yield func(string, Node) bool) {
+		for _, m := range n.members {
+			if !yield(m.key, m.value) {
+				return
+			}
+		}
+	}
 }
 
 func (n *ObjectNode) Values() map[string]Node {
-	return n.values
+	values := make(map[string]Node, len(n.index))
+	for key, i := range n.index {
+		values[key] = n.members[i].value
+	}
+	return values
 }
 
 func (n *ObjectNode) Value(key string) (Node, bool) {
-	value, ok := n.values[key]
-	return value, ok
+	m, ok := n.Member(key)
+	if !ok {
+		return nil, false
+	}
+	return m.value, true
+}
+
+func (n *ObjectNode) Duplicates() []*Member {
+	return n.duplicates
+}
+
+// DanglingComments returns the comments between the last member and the
+// closing brace that were not attached as trailing comments.
+func (n *ObjectNode) DanglingComments() []*CommentNode {
+	return n.dangling
+}
+
+// add appends a member, resolving a duplicate key according to policy. Only
+// the values of members that are kept are attached to n, so that a shadowed
+// value has no path.
+func (n *ObjectNode) add(m *Member, policy DuplicateKeyPolicy) error {
+	if n.index == nil {
+		n.index = make(map[string]int)
+	}
+
+	i, ok := n.index[m.key]
+	if !ok {
+		n.index[m.key] = len(n.members)
+		n.members = append(n.members, m)
+		attach(m.value, n, m.segment)
+		return nil
+	}
+
+	switch policy {
+	case FirstWins:
+		n.duplicates = append(n.duplicates, m)
+	case ErrorOnDuplicate:
+		return &DuplicateKeyError{Key: m.key, First: n.members[i], Duplicate: m}
+	case CollectDuplicates:
+		n.duplicates = append(n.duplicates, m)
+		n.index[m.key] = len(n.members)
+		n.members = append(n.members, m)
+		attach(m.value, n, m.segment)
+	default:
+		n.duplicates = append(n.duplicates, n.members[i])
+		detach(n.members[i].value)
+		n.members[i] = m
+		attach(m.value, n, m.segment)
+	}
+	return nil
 }
 
 type ArrayNode struct {
-	values []Node
+	values   []Node
+	dangling []*CommentNode
+	Position
 }
 
 func (n *ArrayNode) Kind() Kind {
@@ -116,6 +371,12 @@ func (n *ArrayNode) Values() []Node {
 	return n.values
 }
 
+// DanglingComments returns the comments between the last element and the
+// closing bracket that were not attached as trailing comments.
+func (n *ArrayNode) DanglingComments() []*CommentNode {
+	return n.dangling
+}
+
 func (n *ArrayNode) Value(index int) (Node, bool) {
 	if index < 0 || index >= len(n.values) {
 		return nil, false
@@ -123,12 +384,16 @@ func (n *ArrayNode) Value(index int) (Node, bool) {
 	return n.values[index], true
 }
 
+// String creates a StringNode from a string literal. If the literal is wrapped
+// in matching quotes, they are removed and recorded as the node's quote.
 func String(literal string) *StringNode {
 	quote := rune(0)
-	if strings.HasPrefix(literal, "'") {
-		quote = '\''
-	} else if strings.HasPrefix(literal, "\"") {
-		quote = '"'
+	if len(literal) >= 2 && literal[0] == literal[len(literal)-1] {
+		switch literal[0] {
+		case '\'', '"':
+			quote = rune(literal[0])
+			literal = literal[1 : len(literal)-1]
+		}
 	}
 	return &StringNode{value: literal, quote: quote}
 }
@@ -136,6 +401,7 @@ func String(literal string) *StringNode {
 type StringNode struct {
 	value string
 	quote rune
+	Position
 }
 
 func (n *StringNode) Kind() Kind {
@@ -150,56 +416,118 @@ func (n *StringNode) Quote() rune {
 	return n.quote
 }
 
-type NumberNode struct {
-	raw string
+type BooleanNode struct {
+	value bool
+	Position
+}
+
+func (n *BooleanNode) Kind() Kind {
+	return BOOLEAN
+}
+
+func (n *BooleanNode) Value() bool {
+	return n.value
+}
+
+type NullNode struct {
+	Position
+}
+
+func (n *NullNode) Kind() Kind {
+	return NULL
 }
 
-func (n *NumberNode) Kind() Kind {
-	return NUMBER
+func (n *NullNode) Value() any {
+	return nil
 }
 
-func (n *NumberNode) Int() (int, error) {
-	return strconv.Atoi(n.raw)
+type InfinityNode struct {
+	negative bool
+	Position
 }
 
-func (n *NumberNode) Int64() (int64, error) {
-	return strconv.ParseInt(n.raw, 10, 64)
+func (n *InfinityNode) Kind() Kind {
+	return INFINITY
 }
 
-func (n *NumberNode) Float64() (float64, error) {
-	return strconv.ParseFloat(n.raw, 64)
+func (n *InfinityNode) Negative() bool {
+	return n.negative
 }
 
-func (n *NumberNode) Value() (any, error) {
-	if strings.Contains(n.raw, ".") {
-		return n.Float64()
+// Sign returns -1 for negative infinity and +1 otherwise.
+func (n *InfinityNode) Sign() int {
+	if n.negative {
+		return -1
 	}
-	return n.Int64()
+	return 1
 }
 
-func (n *NumberNode) String() string {
-	return n.raw
+func (n *InfinityNode) Value() float64 {
+	return math.Inf(n.Sign())
 }
 
-type BooleanNode struct {
-	value bool
+func (n *InfinityNode) String() string {
+	if n.negative {
+		return "-Infinity"
+	}
+	return "Infinity"
 }
 
-func (n *BooleanNode) Kind() Kind {
-	return BOOLEAN
+type NaNNode struct {
+	Position
 }
 
-func (n *BooleanNode) Value() bool {
-	return n.value
+func (n *NaNNode) Kind() Kind {
+	return NAN
 }
 
-type NullNode struct {
+func (n *NaNNode) Value() float64 {
+	return math.NaN()
 }
 
-func (n *NullNode) Kind() Kind {
-	return NULL
+func (n *NaNNode) String() string {
+	return "NaN"
 }
 
-func (n *NullNode) Value() any {
-	return nil
+// Comment creates a CommentNode from a comment literal, including its "//" or
+// "/* */" delimiters.
+func Comment(literal string) *CommentNode {
+	return &CommentNode{raw: literal, block: strings.HasPrefix(literal, "/*")}
+}
+
+// CommentNode is a line or block comment. Comments are not part of the value
+// tree; instead they are attached to a neighboring node as one of its leading
+// or trailing comments.
+type CommentNode struct {
+	raw   string
+	block bool
+	Position
+}
+
+func (n *CommentNode) Kind() Kind {
+	return COMMENT
+}
+
+func (n *CommentNode) IsBlock() bool {
+	return n.block
+}
+
+// Text returns the body of the comment, without the comment delimiters.
+func (n *CommentNode) Text() string {
+	if n.block {
+		return strings.TrimSuffix(strings.TrimPrefix(n.raw, "/*"), "*/")
+	}
+	return strings.TrimPrefix(n.raw, "//")
+}
+
+func (n *CommentNode) String() string {
+	return n.raw
+}
+
+// Path returns the path of the node the comment is attached to.
+func (n *CommentNode) Path() *path.Path {
+	if n.parent == nil {
+		return path.Must()
+	}
+	return n.parent.Path()
 }
@@ -0,0 +1,392 @@
+package ast
+
+import (
+	"encoding/json"
+	"fmt"
+	"iter"
+	"math"
+	"math/big"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// Number is a JSON5 number literal, kept as written. It plays the same role as
+// encoding/json's Number, but also accepts JSON5 spellings such as hex.
+type Number string
+
+func (n Number) String() string {
+	return string(n)
+}
+
+func (n Number) Int64() (int64, error) {
+	return (&NumberNode{raw: string(n)}).Int64()
+}
+
+func (n Number) Float64() (float64, error) {
+	return (&NumberNode{raw: string(n)}).Float64()
+}
+
+// OrderedMap is a map that remembers the order in which keys were added.
+type OrderedMap struct {
+	keys   []string
+	values map[string]any
+}
+
+func NewOrderedMap() *OrderedMap {
+	return &OrderedMap{values: make(map[string]any)}
+}
+
+func (m *OrderedMap) Len() int {
+	return len(m.keys)
+}
+
+func (m *OrderedMap) Keys() []string {
+	return m.keys
+}
+
+func (m *OrderedMap) Get(key string) (any, bool) {
+	value, ok := m.values[key]
+	return value, ok
+}
+
+// Set sets the value for key. An existing key keeps its place in the order.
+func (m *OrderedMap) Set(key string, value any) {
+	if _, ok := m.values[key]; !ok {
+		m.keys = append(m.keys, key)
+	}
+	m.values[key] = value
+}
+
+func (m *OrderedMap) Delete(key string) {
+	if _, ok := m.values[key]; !ok {
+		return
+	}
+	delete(m.values, key)
+	m.keys = slices.DeleteFunc(m.keys, func(k string) bool {
+		return k == key
+	})
+}
+
+func (m *OrderedMap) All() iter.Seq2[string, any] {
+	return func(yield func(string, any) bool) {
+		for _, key := range m.keys {
+			if !yield(key, m.values[key]) {
+				return
+			}
+		}
+	}
+}
+
+type NonFinitePolicy int
+
+const (
+	// NonFiniteAsFloat converts Infinity and NaN to the equivalent float64.
+	NonFiniteAsFloat NonFinitePolicy = iota
+	// NonFiniteAsString converts Infinity and NaN to the strings "Infinity",
+	// "-Infinity" and "NaN".
+	NonFiniteAsString
+	// NonFiniteAsNull converts Infinity and NaN to nil.
+	NonFiniteAsNull
+	// NonFiniteAsError fails the conversion on Infinity or NaN.
+	NonFiniteAsError
+)
+
+type ConvertOption func(*converter)
+
+// UseNumber converts numbers to Number rather than float64, keeping their
+// exact spelling.
+func UseNumber() ConvertOption {
+	return func(c *converter) {
+		c.useNumber = true
+	}
+}
+
+// OrderedMaps converts objects to *OrderedMap rather than map[string]any, so
+// that the order of keys is preserved.
+func OrderedMaps() ConvertOption {
+	return func(c *converter) {
+		c.orderedMaps = true
+	}
+}
+
+// NonFinite sets how Infinity and NaN are represented. The default is
+// NonFiniteAsFloat.
+func NonFinite(policy NonFinitePolicy) ConvertOption {
+	return func(c *converter) {
+		c.nonFinite = policy
+	}
+}
+
+type converter struct {
+	useNumber   bool
+	orderedMaps bool
+	nonFinite   NonFinitePolicy
+
+	// visited holds the maps, slices and pointers being converted by
+	// FromInterface, to detect cycles
+	visited map[visit]bool
+}
+
+type visit struct {
+	ptr uintptr
+	typ reflect.Type
+	len int
+}
+
+// ToInterface converts a node to plain Go values: map[string]any (or
+// *OrderedMap), []any, string, float64 (or Number), bool and nil.
+func ToInterface(node Node, opts ...ConvertOption) (any, error) {
+	c := &converter{}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c.toInterface(node)
+}
+
+func (c *converter) toInterface(node Node) (any, error) {
+	switch n := node.(type) {
+	case *ObjectNode:
+		if c.orderedMaps {
+			m := NewOrderedMap()
+			for key, value := range n.All() {
+				v, err := c.toInterface(value)
+				if err != nil {
+					return nil, err
+				}
+				m.Set(key, v)
+			}
+			return m, nil
+		}
+		m := make(map[string]any, n.Len())
+		for key, value := range n.All() {
+			v, err := c.toInterface(value)
+			if err != nil {
+				return nil, err
+			}
+			m[key] = v
+		}
+		return m, nil
+	case *ArrayNode:
+		values := make([]any, 0, n.Len())
+		for _, value := range n.values {
+			v, err := c.toInterface(value)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, v)
+		}
+		return values, nil
+	case *StringNode:
+		return n.Value(), nil
+	case *NumberNode:
+		if c.useNumber {
+			return Number(n.raw), nil
+		}
+		return n.Float64()
+	case *BooleanNode:
+		return n.Value(), nil
+	case *NullNode:
+		return nil, nil
+	case *InfinityNode:
+		return c.convertNonFinite(n, n.String(), n.Value())
+	case *NaNNode:
+		return c.convertNonFinite(n, n.String(), n.Value())
+	default:
+		return nil, fmt.Errorf("cannot convert %T at %q", node, node.Path())
+	}
+}
+
+func (c *converter) convertNonFinite(node Node, s string, f float64) (any, error) {
+	switch c.nonFinite {
+	case NonFiniteAsString:
+		return s, nil
+	case NonFiniteAsNull:
+		return nil, nil
+	case NonFiniteAsError:
+		return nil, fmt.Errorf("unsupported value %s at %q", s, node.Path())
+	default:
+		return f, nil
+	}
+}
+
+// FromInterface builds a tree from Go values. It accepts the values produced
+// by ToInterface, along with any integer or float type, json.Number,
+// *big.Int, *big.Float, existing nodes, and maps with string keys, slices,
+// arrays and pointers of those. Keys of plain maps are sorted. Nodes that
+// already belong to a tree are cloned, and cyclic values are rejected.
+//
+// With NonFinite(NonFiniteAsString), the strings "Infinity", "-Infinity" and
+// "NaN" are converted back to Infinity and NaN nodes.
+func FromInterface(v any, opts ...ConvertOption) (Node, error) {
+	c := &converter{visited: make(map[visit]bool)}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c.fromValue(reflect.ValueOf(v))
+}
+
+func (c *converter) fromValue(v reflect.Value) (Node, error) {
+	if !v.IsValid() {
+		return NewNull(), nil
+	}
+	if v.Kind() == reflect.Interface && !v.IsNil() {
+		v = v.Elem()
+	}
+	if (v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface) && v.IsNil() {
+		return NewNull(), nil
+	}
+
+	switch x := v.Interface().(type) {
+	case Node:
+		if x.Parent() != nil {
+			return Clone(x), nil
+		}
+		return x, nil
+	case *OrderedMap:
+		if x == nil {
+			return NewNull(), nil
+		}
+		if err := c.enter(v); err != nil {
+			return nil, err
+		}
+		defer c.leave(v)
+		obj := NewObject()
+		for key, value := range x.All() {
+			node, err := c.fromValue(reflect.ValueOf(value))
+			if err != nil {
+				return nil, err
+			}
+			obj.Set(key, node)
+		}
+		return obj, nil
+	case Number:
+		return ParseNumber(string(x))
+	case json.Number:
+		return ParseNumber(string(x))
+	case *big.Int:
+		if x == nil {
+			return NewNull(), nil
+		}
+		return NewBigInt(x), nil
+	case *big.Float:
+		if x == nil {
+			return NewNull(), nil
+		}
+		if x.IsInf() {
+			return NewInfinity(x.Signbit()), nil
+		}
+		return NewBigFloat(x), nil
+	}
+
+	switch v.Kind() {
+	case reflect.Interface, reflect.Pointer:
+		if v.IsNil() {
+			return NewNull(), nil
+		}
+		if v.Kind() == reflect.Pointer {
+			if err := c.enter(v); err != nil {
+				return nil, err
+			}
+			defer c.leave(v)
+		}
+		return c.fromValue(v.Elem())
+	case reflect.Bool:
+		return NewBool(v.Bool()), nil
+	case reflect.String:
+		if c.nonFinite == NonFiniteAsString {
+			switch v.String() {
+			case "Infinity":
+				return NewInfinity(false), nil
+			case "-Infinity":
+				return NewInfinity(true), nil
+			case "NaN":
+				return NewNaN(), nil
+			}
+		}
+		return NewString(v.String()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return NewNumber(v.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return NewNumber(v.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		f := v.Float()
+		switch {
+		case math.IsNaN(f):
+			return NewNaN(), nil
+		case math.IsInf(f, 0):
+			return NewInfinity(f < 0), nil
+		case v.Kind() == reflect.Float32:
+			return NewNumber(float32(f)), nil
+		default:
+			return NewNumber(f), nil
+		}
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("unsupported map key type %s", v.Type().Key())
+		}
+		if v.IsNil() {
+			return NewNull(), nil
+		}
+		if err := c.enter(v); err != nil {
+			return nil, err
+		}
+		defer c.leave(v)
+		keys := v.MapKeys()
+		slices.SortFunc(keys, func(a, b reflect.Value) int {
+			return strings.Compare(a.String(), b.String())
+		})
+		obj := NewObject()
+		for _, key := range keys {
+			node, err := c.fromValue(v.MapIndex(key))
+			if err != nil {
+				return nil, err
+			}
+			obj.Set(key.String(), node)
+		}
+		return obj, nil
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice {
+			if v.IsNil() {
+				return NewNull(), nil
+			}
+			if err := c.enter(v); err != nil {
+				return nil, err
+			}
+			defer c.leave(v)
+		}
+		arr := NewArray()
+		for i := range v.Len() {
+			node, err := c.fromValue(v.Index(i))
+			if err != nil {
+				return nil, err
+			}
+			arr.Append(node)
+		}
+		return arr, nil
+	default:
+		return nil, fmt.Errorf("unsupported type %s", v.Type())
+	}
+}
+
+// enter marks the map, slice or pointer v as being converted, failing if it
+// already is.
+func (c *converter) enter(v reflect.Value) error {
+	key := visit{v.Pointer(), v.Type(), 0}
+	if v.Kind() == reflect.Slice {
+		key.len = v.Len()
+	}
+	if c.visited[key] {
+		return fmt.Errorf("encountered a cycle via %s", v.Type())
+	}
+	c.visited[key] = true
+	return nil
+}
+
+func (c *converter) leave(v reflect.Value) {
+	key := visit{v.Pointer(), v.Type(), 0}
+	if v.Kind() == reflect.Slice {
+		key.len = v.Len()
+	}
+	delete(c.visited, key)
+}
@@ -0,0 +1,218 @@
+package ast
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/Roundaround/json5-go/path"
+)
+
+func TestToInterface(t *testing.T) {
+	node, err := Parse(`{b: [1, 'two', true, null], a: 0x10, c: {d: -Infinity}}`)
+	if err != nil {
+		t.Fatalf("returned unexpected error %v", err)
+	}
+
+	v, err := ToInterface(node)
+	if err != nil {
+		t.Fatalf("returned unexpected error %v", err)
+	}
+	expected := map[string]any{
+		"b": []any{1.0, "two", true, nil},
+		"a": 16.0,
+		"c": map[string]any{"d": math.Inf(-1)},
+	}
+	if !reflect.DeepEqual(v, expected) {
+		t.Errorf("expected %v, got %v", expected, v)
+	}
+
+	v, err = ToInterface(node, UseNumber(), OrderedMaps())
+	if err != nil {
+		t.Fatalf("returned unexpected error %v", err)
+	}
+	m, ok := v.(*OrderedMap)
+	if !ok {
+		t.Fatalf("expected *OrderedMap, got %T", v)
+	}
+	if keys := m.Keys(); !reflect.DeepEqual(keys, []string{"b", "a", "c"}) {
+		t.Errorf("expected keys [b a c], got %v", keys)
+	}
+	if a, _ := m.Get("a"); a != Number("0x10") {
+		t.Errorf("expected Number 0x10, got %#v", a)
+	}
+}
+
+func TestToInterface_NonFinite(t *testing.T) {
+	node, err := Parse(`[Infinity, -Infinity, NaN]`)
+	if err != nil {
+		t.Fatalf("returned unexpected error %v", err)
+	}
+
+	tests := []struct {
+		policy   NonFinitePolicy
+		expected []any
+		err      bool
+	}{
+		{NonFiniteAsString, []any{"Infinity", "-Infinity", "NaN"}, false},
+		{NonFiniteAsNull, []any{nil, nil, nil}, false},
+		{NonFiniteAsError, nil, true},
+	}
+
+	for _, tt := range tests {
+		v, err := ToInterface(node, NonFinite(tt.policy))
+		if tt.err {
+			if err == nil {
+				t.Errorf("policy %d: expected error", tt.policy)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("policy %d: returned unexpected error %v", tt.policy, err)
+		}
+		if !reflect.DeepEqual(v, tt.expected) {
+			t.Errorf("policy %d: expected %v, got %v", tt.policy, tt.expected, v)
+		}
+	}
+
+	v, _ := ToInterface(node)
+	values := v.([]any)
+	if !math.IsInf(values[0].(float64), 1) || !math.IsInf(values[1].(float64), -1) || !math.IsNaN(values[2].(float64)) {
+		t.Errorf("expected [+Inf -Inf NaN], got %v", values)
+	}
+}
+
+func TestFromInterface(t *testing.T) {
+	m := NewOrderedMap()
+	m.Set("z", json.Number("1.5"))
+	m.Set("y", Number("0xFF"))
+
+	cyclicMap := map[string]any{}
+	cyclicMap["self"] = cyclicMap
+	cyclicSlice := []any{1, nil}
+	cyclicSlice[1] = cyclicSlice
+	cyclicOrdered := NewOrderedMap()
+	cyclicOrdered.Set("self", cyclicOrdered)
+	shared := []int{1}
+
+	tests := []struct {
+		name     string
+		value    any
+		expected string
+	}{
+		{"nil", nil, "null"},
+		{"map", map[string]any{"b": 1, "a": []int{2, 3}}, `{"a":[2,3],"b":1}`},
+		{"ordered", m, `{"z":1.5,"y":0xFF}`},
+		{"pointer", &struct{}{}, ""},
+		{"uint", uint64(math.MaxUint64), "18446744073709551615"},
+		{"float32", float32(0.1), "0.1"},
+		{"big", new(big.Int).Lsh(big.NewInt(1), 70), "1180591620717411303424"},
+		{"non-finite", []float64{math.Inf(1), math.NaN()}, "[Infinity,NaN]"},
+		{"nil slice", []string(nil), "null"},
+		{"node", NewString("x"), `"x"`},
+		{"nil node", (*ObjectNode)(nil), "null"},
+		{"nil nodes", map[string]any{"a": []any{(*ArrayNode)(nil)}, "b": Node(nil)}, `{"a":[null],"b":null}`},
+		{"cyclic map", cyclicMap, ""},
+		{"cyclic slice", cyclicSlice, ""},
+		{"cyclic ordered map", cyclicOrdered, ""},
+		{"shared", map[string]any{"a": shared, "b": shared}, `{"a":[1],"b":[1]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node, err := FromInterface(tt.value)
+			if tt.expected == "" {
+				if err == nil {
+					t.Errorf("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("returned unexpected error %v", err)
+			}
+			if s := render(node); s != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, s)
+			}
+		})
+	}
+}
+
+func TestFromInterface_ParentedNode(t *testing.T) {
+	root, err := Parse(`{a: [1, 2]}`)
+	if err != nil {
+		t.Fatalf("returned unexpected error %v", err)
+	}
+	a, _ := root.(*ObjectNode).Value("a")
+
+	node, err := FromInterface(map[string]any{"b": a})
+	if err != nil {
+		t.Fatalf("returned unexpected error %v", err)
+	}
+	b, _ := node.(*ObjectNode).Value("b")
+	if b == a {
+		t.Errorf("expected a node from another tree to be cloned")
+	}
+	if a.Parent() != root || !a.Path().Equals(path.Must("a")) {
+		t.Errorf("expected the original node to stay in its tree, got path %q", a.Path())
+	}
+	if s := render(node); s != `{"b":[1,2]}` {
+		t.Errorf("expected {\"b\":[1,2]}, got %s", s)
+	}
+}
+
+func TestFromInterface_RoundTrip(t *testing.T) {
+	node, err := Parse(`{list: [1, 'two', Infinity], nested: {ok: true}}`)
+	if err != nil {
+		t.Fatalf("returned unexpected error %v", err)
+	}
+
+	opts := []ConvertOption{UseNumber(), OrderedMaps(), NonFinite(NonFiniteAsString)}
+	v, err := ToInterface(node, opts...)
+	if err != nil {
+		t.Fatalf("returned unexpected error %v", err)
+	}
+	back, err := FromInterface(v, opts...)
+	if err != nil {
+		t.Fatalf("returned unexpected error %v", err)
+	}
+	if s := render(back); s != `{"list":[1,"two",Infinity],"nested":{"ok":true}}` {
+		t.Errorf("unexpected round trip %s", s)
+	}
+}
+
+// render writes a compact, JSON-like form of a tree for comparison in tests.
+func render(node Node) string {
+	switch n := node.(type) {
+	case *ObjectNode:
+		parts := make([]string, 0, n.Len())
+		for key, value := range n.All() {
+			parts = append(parts, strconv.Quote(key)+":"+render(value))
+		}
+		return "{" + strings.Join(parts, ",") + "}"
+	case *ArrayNode:
+		parts := make([]string, 0, n.Len())
+		for _, value := range n.Values() {
+			parts = append(parts, render(value))
+		}
+		return "[" + strings.Join(parts, ",") + "]"
+	case *StringNode:
+		return strconv.Quote(n.Value())
+	case *NumberNode:
+		return n.String()
+	case *BooleanNode:
+		return strconv.FormatBool(n.Value())
+	case *NullNode:
+		return "null"
+	case *InfinityNode:
+		return n.String()
+	case *NaNNode:
+		return "NaN"
+	default:
+		return fmt.Sprintf("%T", node)
+	}
+}
@@ -0,0 +1,383 @@
+package ast
+
+import (
+	"fmt"
+	"maps"
+	"math"
+	"math/big"
+	"slices"
+	"strconv"
+
+	"github.com/Roundaround/json5-go/path"
+)
+
+type integer interface {
+	int | int8 | int16 | int32 | int64 | uint | uint8 | uint16 | uint32 | uint64
+}
+
+type float interface {
+	float32 | float64
+}
+
+func NewObject() *ObjectNode {
+	return &ObjectNode{index: make(map[string]int)}
+}
+
+func NewArray(values ...Node) *ArrayNode {
+	n := &ArrayNode{values: make([]Node, 0, len(values))}
+	n.Append(values...)
+	return n
+}
+
+func NewString(value string) *StringNode {
+	return &StringNode{value: value, quote: '"'}
+}
+
+// NewNumber creates a NumberNode from any integer or floating-point value. It
+// panics if v is NaN or infinite; use NewNaN or NewInfinity for those.
+func NewNumber[N integer | float](v N) *NumberNode {
+	switch f := any(v).(type) {
+	case float32:
+		return &NumberNode{raw: formatFloat(float64(f), 32)}
+	case float64:
+		return &NumberNode{raw: formatFloat(f, 64)}
+	}
+	if v < 0 {
+		return &NumberNode{raw: strconv.FormatInt(int64(v), 10)}
+	}
+	return &NumberNode{raw: strconv.FormatUint(uint64(v), 10)}
+}
+
+func NewBigInt(v *big.Int) *NumberNode {
+	return &NumberNode{raw: v.String()}
+}
+
+// NewBigFloat creates a NumberNode holding every digit of v. It panics if v is
+// infinite; use NewInfinity instead.
+func NewBigFloat(v *big.Float) *NumberNode {
+	if v.IsInf() {
+		panic("ast: NewBigFloat called with an infinite value")
+	}
+	return &NumberNode{raw: v.Text('g', -1)}
+}
+
+// ParseNumber creates a NumberNode from a JSON5 number literal, preserving its
+// spelling.
+func ParseNumber(literal string) (*NumberNode, error) {
+	n := &NumberNode{raw: literal}
+	if !n.valid() {
+		return nil, fmt.Errorf("invalid number %q", literal)
+	}
+	return n, nil
+}
+
+func NewInfinity(negative bool) *InfinityNode {
+	return &InfinityNode{negative: negative}
+}
+
+func NewNaN() *NaNNode {
+	return &NaNNode{}
+}
+
+func NewBool(value bool) *BooleanNode {
+	return &BooleanNode{value: value}
+}
+
+func NewNull() *NullNode {
+	return &NullNode{}
+}
+
+// Set sets the value for key. An existing member keeps its place in the
+// object; otherwise a new member is appended. As with every method that adds
+// nodes, a value that already belongs to a tree is first removed from it.
+func (n *ObjectNode) Set(key string, value Node) {
+	if m, ok := n.Member(key); ok {
+		if m.value == value {
+			return
+		}
+		unlink(value)
+		detach(m.value)
+		m.value = value
+		attach(value, n, m.segment)
+		return
+	}
+	n.insert(len(n.members), key, value)
+}
+
+// InsertAt inserts a new member at the given index. It fails if the key is
+// already present or the index is out of range.
+func (n *ObjectNode) InsertAt(index int, key string, value Node) error {
+	if _, ok := n.index[key]; ok {
+		return fmt.Errorf("key %q already exists", key)
+	}
+	if index < 0 || index > len(n.members) {
+		return fmt.Errorf("index %d out of range [0, %d]", index, len(n.members))
+	}
+	n.insert(index, key, value)
+	return nil
+}
+
+// Delete removes every member with the given key, reporting whether any were
+// found.
+func (n *ObjectNode) Delete(key string) bool {
+	if _, ok := n.index[key]; !ok {
+		return false
+	}
+	n.members = slices.DeleteFunc(n.members, func(m *Member) bool {
+		if m.key != key {
+			return false
+		}
+		detach(m.value)
+		m.parent = nil
+		return true
+	})
+	n.duplicates = slices.DeleteFunc(n.duplicates, func(m *Member) bool {
+		return m.key == key
+	})
+	n.reindex()
+	return true
+}
+
+// Rename changes the key of a member, keeping its value and place in the
+// object. It fails if from does not exist or to already does.
+func (n *ObjectNode) Rename(from, to string) error {
+	if _, ok := n.index[from]; !ok {
+		return fmt.Errorf("key %q does not exist", from)
+	}
+	if from == to {
+		return nil
+	}
+	if _, ok := n.index[to]; ok {
+		return fmt.Errorf("key %q already exists", to)
+	}
+	for _, m := range n.members {
+		if m.key == from {
+			m.key = to
+			m.segment = path.Key(to)
+			attach(m.value, n, m.segment)
+		}
+	}
+	n.reindex()
+	return nil
+}
+
+func (n *ObjectNode) insert(index int, key string, value Node) {
+	if value != nil && value.Parent() == Node(n) {
+		// Moving a member forward shifts the index it is inserted at
+		if i := slices.IndexFunc(n.members, func(m *Member) bool { return m.value == value }); i >= 0 && i < index {
+			index--
+		}
+	}
+	unlink(value)
+	m := &Member{key: key, value: value}
+	m.parent = n
+	m.segment = path.Key(key)
+	attach(value, n, m.segment)
+	n.members = slices.Insert(n.members, index, m)
+	n.reindex()
+}
+
+// reindex rebuilds the key index, resolving repeated keys to their last
+// occurrence.
+func (n *ObjectNode) reindex() {
+	n.index = make(map[string]int, len(n.members))
+	for i, m := range n.members {
+		n.index[m.key] = i
+	}
+}
+
+func (n *ArrayNode) Append(values ...Node) {
+	for _, value := range values {
+		unlink(value)
+	}
+	n.values = append(n.values, values...)
+	n.renumber(len(n.values) - len(values))
+}
+
+// Insert inserts values before the element at index. An index equal to Len
+// appends.
+func (n *ArrayNode) Insert(index int, values ...Node) error {
+	if index < 0 || index > len(n.values) {
+		return fmt.Errorf("index %d out of range [0, %d]", index, len(n.values))
+	}
+	for _, value := range values {
+		if value != nil && value.Parent() == Node(n) {
+			// Moving an element forward shifts the index it is inserted at
+			if i := slices.Index(n.values, value); i >= 0 && i < index {
+				index--
+			}
+		}
+		unlink(value)
+	}
+	n.values = slices.Insert(n.values, index, values...)
+	n.renumber(index)
+	return nil
+}
+
+// Remove removes and returns the element at index.
+func (n *ArrayNode) Remove(index int) (Node, error) {
+	if index < 0 || index >= len(n.values) {
+		return nil, fmt.Errorf("index %d out of range [0, %d)", index, len(n.values))
+	}
+	removed := n.values[index]
+	n.values = slices.Delete(n.values, index, index+1)
+	detach(removed)
+	n.renumber(index)
+	return removed, nil
+}
+
+// Replace replaces the element at index, returning the previous element.
+func (n *ArrayNode) Replace(index int, value Node) (Node, error) {
+	if index < 0 || index >= len(n.values) {
+		return nil, fmt.Errorf("index %d out of range [0, %d)", index, len(n.values))
+	}
+	replaced := n.values[index]
+	if replaced == value {
+		return replaced, nil
+	}
+	unlink(value)
+	index = slices.Index(n.values, replaced)
+	detach(replaced)
+	n.values[index] = value
+	attach(value, n, path.Index(index))
+	return replaced, nil
+}
+
+// renumber updates the parent and segment of every element from start onward.
+func (n *ArrayNode) renumber(start int) {
+	for i := start; i < len(n.values); i++ {
+		attach(n.values[i], n, path.Index(i))
+	}
+}
+
+// unlink removes node from the object or array it belongs to, if any, so that
+// it can be added elsewhere without two parents claiming it.
+func unlink(node Node) {
+	if node == nil {
+		return
+	}
+	switch parent := node.Parent().(type) {
+	case *ObjectNode:
+		if i := slices.IndexFunc(parent.members, func(m *Member) bool { return m.value == node }); i >= 0 {
+			parent.removeAt(i)
+		}
+	case *ArrayNode:
+		if i := slices.Index(parent.values, node); i >= 0 {
+			parent.Remove(i)
+		}
+	}
+}
+
+func detach(node Node) {
+	attach(node, nil, path.Segment{})
+}
+
+func formatFloat(v float64, bitSize int) string {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		panic(fmt.Sprintf("ast: NewNumber called with non-finite value %v", v))
+	}
+	return strconv.FormatFloat(v, 'g', -1, bitSize)
+}
+
+// removeAt removes the member at index, leaving any other members with the
+// same key in place.
+func (n *ObjectNode) removeAt(index int) {
+	m := n.members[index]
+	detach(m.value)
+	m.parent = nil
+	n.members = slices.Delete(n.members, index, index+1)
+	n.reindex()
+}
+
+// Clone returns a deep copy of node, including its source positions and
+// comments. The copy has no parent.
+func Clone(node Node) Node {
+	c := clone(node)
+	detach(c)
+	return c
+}
+
+func clone(node Node) Node {
+	switch n := node.(type) {
+	case *ObjectNode:
+		c := &ObjectNode{index: maps.Clone(n.index), Position: n.Position}
+		c.cloneComments(c)
+		c.dangling = cloneComments(n.dangling, c)
+		members := make(map[*Member]*Member, len(n.members))
+		cloneMember := func(m *Member) *Member {
+			if cm, ok := members[m]; ok {
+				return cm
+			}
+			cm := &Member{key: m.key, value: clone(m.value), Position: m.Position}
+			cm.parent = c
+			members[m] = cm
+			return cm
+		}
+		for _, m := range n.members {
+			cm := cloneMember(m)
+			attach(cm.value, c, m.segment)
+			c.members = append(c.members, cm)
+		}
+		for _, m := range n.duplicates {
+			c.duplicates = append(c.duplicates, cloneMember(m))
+		}
+		return c
+	case *ArrayNode:
+		c := &ArrayNode{values: make([]Node, len(n.values)), Position: n.Position}
+		c.cloneComments(c)
+		c.dangling = cloneComments(n.dangling, c)
+		for i, value := range n.values {
+			c.values[i] = clone(value)
+			attach(c.values[i], c, path.Index(i))
+		}
+		return c
+	case *StringNode:
+		c := *n
+		c.cloneComments(&c)
+		return &c
+	case *NumberNode:
+		c := *n
+		c.cloneComments(&c)
+		return &c
+	case *BooleanNode:
+		c := *n
+		c.cloneComments(&c)
+		return &c
+	case *NullNode:
+		c := *n
+		c.cloneComments(&c)
+		return &c
+	case *InfinityNode:
+		c := *n
+		c.cloneComments(&c)
+		return &c
+	case *NaNNode:
+		c := *n
+		c.cloneComments(&c)
+		return &c
+	case *CommentNode:
+		c := *n
+		return &c
+	default:
+		return node
+	}
+}
+
+// cloneComments replaces the attached comments with copies owned by owner.
+func (p *Position) cloneComments(owner Node) {
+	p.leading = cloneComments(p.leading, owner)
+	p.trailing = cloneComments(p.trailing, owner)
+}
+
+func cloneComments(comments []*CommentNode, owner Node) []*CommentNode {
+	if comments == nil {
+		return nil
+	}
+	clones := make([]*CommentNode, len(comments))
+	for i, comment := range comments {
+		c := *comment
+		c.parent = owner
+		clones[i] = &c
+	}
+	return clones
+}
@@ -0,0 +1,229 @@
+package ast
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"slices"
+	"testing"
+)
+
+func TestNewNumber(t *testing.T) {
+	tests := []struct {
+		node *NumberNode
+		raw  string
+	}{
+		{NewNumber(42), "42"},
+		{NewNumber(int8(-8)), "-8"},
+		{NewNumber(uint64(math.MaxUint64)), "18446744073709551615"},
+		{NewNumber(1.5), "1.5"},
+		{NewNumber(float32(0.1)), "0.1"},
+		{NewNumber(1e21), "1e+21"},
+		{NewBigInt(new(big.Int).Lsh(big.NewInt(1), 100)), "1267650600228229401496703205376"},
+		{NewBigFloat(big.NewFloat(-2.25)), "-2.25"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			if tt.node.String() != tt.raw {
+				t.Errorf("expected %q, got %q", tt.raw, tt.node.String())
+			}
+			if !tt.node.valid() {
+				t.Errorf("expected %q to be a valid number", tt.node.String())
+			}
+		})
+	}
+
+	if _, err := ParseNumber("0x1F"); err != nil {
+		t.Errorf("returned unexpected error %v", err)
+	}
+	if _, err := ParseNumber("1.2.3"); err == nil {
+		t.Errorf("expected error for invalid number")
+	}
+}
+
+func TestObjectNode_Mutation(t *testing.T) {
+	obj := NewObject()
+	obj.Set("a", NewNumber(1))
+	obj.Set("b", NewBool(true))
+	obj.Set("c", NewNull())
+
+	check := func(keys ...string) {
+		t.Helper()
+		if got := obj.Keys(); !slices.Equal(got, keys) {
+			t.Fatalf("expected keys %v, got %v", keys, got)
+		}
+		for key, value := range obj.All() {
+			if value.Parent() != obj {
+				t.Errorf("%s: expected parent to be the object", key)
+			}
+			if value.Path().String() != key {
+				t.Errorf("%s: expected path %q, got %q", key, key, value.Path().String())
+			}
+		}
+	}
+
+	check("a", "b", "c")
+
+	old, _ := obj.Value("b")
+	obj.Set("b", NewString("replaced"))
+	check("a", "b", "c")
+	if old.Parent() != nil {
+		t.Errorf("expected replaced value to be detached")
+	}
+
+	if err := obj.InsertAt(1, "z", NewArray()); err != nil {
+		t.Fatalf("returned unexpected error %v", err)
+	}
+	check("a", "z", "b", "c")
+	if err := obj.InsertAt(0, "a", NewNull()); err == nil {
+		t.Errorf("expected error when inserting an existing key")
+	}
+
+	if err := obj.Rename("z", "y"); err != nil {
+		t.Fatalf("returned unexpected error %v", err)
+	}
+	check("a", "y", "b", "c")
+	if err := obj.Rename("y", "a"); err == nil {
+		t.Errorf("expected error when renaming to an existing key")
+	}
+
+	if !obj.Delete("a") {
+		t.Errorf("expected Delete to report removal")
+	}
+	if obj.Delete("missing") {
+		t.Errorf("expected Delete to report nothing removed")
+	}
+	check("y", "b", "c")
+
+	if v, _ := obj.Value("c"); v.Kind() != NULL {
+		t.Errorf("expected index to resolve %q after edits, got %s", "c", v.Kind())
+	}
+}
+
+func TestArrayNode_Mutation(t *testing.T) {
+	root := NewObject()
+	arr := NewArray(NewNumber(0), NewNumber(1))
+	root.Set("list", arr)
+
+	check := func(raws ...string) {
+		t.Helper()
+		if arr.Len() != len(raws) {
+			t.Fatalf("expected %d elements, got %d", len(raws), arr.Len())
+		}
+		for i, value := range arr.Values() {
+			if got := value.(*NumberNode).String(); got != raws[i] {
+				t.Errorf("%d: expected %s, got %s", i, raws[i], got)
+			}
+			want := fmt.Sprintf("list[%d]", i)
+			if value.Path().String() != want {
+				t.Errorf("%d: expected path %q, got %q", i, want, value.Path().String())
+			}
+		}
+	}
+
+	arr.Append(NewNumber(2))
+	check("0", "1", "2")
+
+	if err := arr.Insert(0, NewNumber(-2), NewNumber(-1)); err != nil {
+		t.Fatalf("returned unexpected error %v", err)
+	}
+	check("-2", "-1", "0", "1", "2")
+
+	removed, err := arr.Remove(2)
+	if err != nil {
+		t.Fatalf("returned unexpected error %v", err)
+	}
+	if removed.Parent() != nil {
+		t.Errorf("expected removed value to be detached")
+	}
+	check("-2", "-1", "1", "2")
+
+	if _, err := arr.Replace(3, NewNumber(3)); err != nil {
+		t.Fatalf("returned unexpected error %v", err)
+	}
+	check("-2", "-1", "1", "3")
+
+	if _, err := arr.Remove(4); err == nil {
+		t.Errorf("expected error for out of range index")
+	}
+	if err := arr.Insert(5); err == nil {
+		t.Errorf("expected error for out of range index")
+	}
+}
+
+func TestMove(t *testing.T) {
+	a, err := Parse(`{x: [1, 2], y: 3}`)
+	if err != nil {
+		t.Fatalf("returned unexpected error %v", err)
+	}
+	b := NewObject()
+	x, _ := a.(*ObjectNode).Value("x")
+
+	b.Set("y", x)
+	if got := render(a); got != `{"y":3}` {
+		t.Errorf("expected moved member to leave its tree, got %s", got)
+	}
+	if got := render(b); got != `{"y":[1,2]}` {
+		t.Errorf("expected moved member in its new tree, got %s", got)
+	}
+	if x.Parent() != b || x.Path().String() != "y" {
+		t.Errorf("expected path y in the new tree, got %q", x.Path())
+	}
+
+	arr := x.(*ArrayNode)
+	first, _ := arr.Value(0)
+	arr.Append(first)
+	if got := render(b); got != `{"y":[2,1]}` {
+		t.Errorf("expected element to move to the end, got %s", got)
+	}
+	if err := arr.Insert(2, arr.Values()[0]); err != nil {
+		t.Fatalf("returned unexpected error %v", err)
+	}
+	if got := render(b); got != `{"y":[1,2]}` {
+		t.Errorf("expected element to move past its neighbor, got %s", got)
+	}
+
+	three, _ := a.(*ObjectNode).Value("y")
+	if _, err := arr.Replace(0, three); err != nil {
+		t.Fatalf("returned unexpected error %v", err)
+	}
+	if got := render(a) + render(b); got != `{}{"y":[3,2]}` {
+		t.Errorf("expected replacement to move between trees, got %s", got)
+	}
+	for i, value := range arr.Values() {
+		if value.Parent() != arr || value.Path().String() != fmt.Sprintf("y[%d]", i) {
+			t.Errorf("%d: expected path y[%d], got %q", i, i, value.Path())
+		}
+	}
+}
+
+func TestClone(t *testing.T) {
+	root, err := Parse("{\n  // note\n  a: [1, 'x'], // trailing\n}")
+	if err != nil {
+		t.Fatalf("returned unexpected error %v", err)
+	}
+	a, _ := root.(*ObjectNode).Value("a")
+
+	c := Clone(a).(*ArrayNode)
+	if c.Parent() != nil || !c.Path().IsEmpty() {
+		t.Errorf("expected clone to be detached")
+	}
+	if !Equal(a, c) || c.Start() != a.Start() {
+		t.Errorf("expected clone to match the original")
+	}
+	if len(c.LeadingComments()) != 1 || c.LeadingComments()[0].Parent() != c {
+		t.Errorf("expected clone to own copies of its comments")
+	}
+	if len(c.TrailingComments()) != 1 || c.TrailingComments()[0] == a.TrailingComments()[0] {
+		t.Errorf("expected trailing comment to be copied")
+	}
+
+	c.Append(NewNull())
+	if a.(*ArrayNode).Len() != 2 {
+		t.Errorf("expected original to be unchanged")
+	}
+	if v, _ := c.Value(1); v.Parent() != c || v.Path().String() != "[1]" {
+		t.Errorf("expected elements to belong to the clone")
+	}
+}
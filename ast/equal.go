@@ -0,0 +1,215 @@
+package ast
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"io"
+	"math"
+	"slices"
+	"strconv"
+)
+
+type EqualOption func(*equality)
+
+// OrderedKeys makes the order of object members significant. By default,
+// objects with the same keys and values are equal in any order.
+func OrderedKeys() EqualOption {
+	return func(e *equality) {
+		e.orderedKeys = true
+	}
+}
+
+// UnorderedArrays compares arrays as multisets, ignoring the order of their
+// elements.
+func UnorderedArrays() EqualOption {
+	return func(e *equality) {
+		e.unorderedArrays = true
+	}
+}
+
+// Tolerance treats numbers as equal when they differ by no more than epsilon
+// once converted to float64.
+func Tolerance(epsilon float64) EqualOption {
+	return func(e *equality) {
+		e.tolerance = epsilon
+	}
+}
+
+type equality struct {
+	orderedKeys     bool
+	unorderedArrays bool
+	tolerance       float64
+}
+
+// Equal reports whether two trees hold the same values, regardless of
+// formatting, comments, quote style or number spelling, so that 0x10, 16 and
+// 1.6e1 are all equal. Only the effective value of a repeated key is compared.
+// NaN is equal to NaN.
+func Equal(a, b Node, opts ...EqualOption) bool {
+	e := &equality{}
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e.equal(a, b)
+}
+
+func (e *equality) equal(a, b Node) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	if a.Kind() != b.Kind() {
+		return false
+	}
+
+	switch a := a.(type) {
+	case *ObjectNode:
+		return e.equalObjects(a, b.(*ObjectNode))
+	case *ArrayNode:
+		return e.equalArrays(a, b.(*ArrayNode))
+	case *StringNode:
+		return a.value == b.(*StringNode).value
+	case *NumberNode:
+		return e.equalNumbers(a, b.(*NumberNode))
+	case *BooleanNode:
+		return a.value == b.(*BooleanNode).value
+	case *InfinityNode:
+		return a.negative == b.(*InfinityNode).negative
+	case *NullNode, *NaNNode:
+		return true
+	default:
+		return false
+	}
+}
+
+func (e *equality) equalObjects(a, b *ObjectNode) bool {
+	keys := a.Keys()
+	if len(keys) != len(b.index) {
+		return false
+	}
+	if e.orderedKeys && !slices.Equal(keys, b.Keys()) {
+		return false
+	}
+	for _, key := range keys {
+		av, _ := a.Value(key)
+		bv, ok := b.Value(key)
+		if !ok || !e.equal(av, bv) {
+			return false
+		}
+	}
+	return true
+}
+
+func (e *equality) equalArrays(a, b *ArrayNode) bool {
+	if len(a.values) != len(b.values) {
+		return false
+	}
+	if !e.unorderedArrays {
+		for i := range a.values {
+			if !e.equal(a.values[i], b.values[i]) {
+				return false
+			}
+		}
+		return true
+	}
+
+	matched := make([]bool, len(b.values))
+outer:
+	for _, av := range a.values {
+		for j, bv := range b.values {
+			if !matched[j] && e.equal(av, bv) {
+				matched[j] = true
+				continue outer
+			}
+		}
+		return false
+	}
+	return true
+}
+
+func (e *equality) equalNumbers(a, b *NumberNode) bool {
+	if canonical(a) == canonical(b) {
+		return true
+	}
+	if e.tolerance <= 0 {
+		return false
+	}
+	af, aerr := a.Float64()
+	bf, berr := b.Float64()
+	return aerr == nil && berr == nil && math.Abs(af-bf) <= e.tolerance
+}
+
+// canonical returns a spelling of a number shared by every literal with the
+// same value.
+func canonical(n *NumberNode) string {
+	d, hex := n.parse()
+	if hex != "" {
+		i, err := n.BigInt()
+		if err != nil {
+			return n.raw
+		}
+		d = parseDecimal(i.String())
+	}
+	if !d.valid {
+		return n.raw
+	}
+	if d.digits == "" {
+		return "0"
+	}
+	s := d.digits + "e" + strconv.Itoa(d.exp)
+	if d.neg {
+		s = "-" + s
+	}
+	return s
+}
+
+// Hash returns a hash of a tree's value that is stable across processes and
+// ignores formatting, comments, key order and number spelling. Trees that are
+// Equal with default options or with OrderedKeys have the same hash.
+func Hash(node Node) uint64 {
+	h := fnv.New64a()
+	hashNode(h, node)
+	return h.Sum64()
+}
+
+func hashNode(w io.Writer, node Node) {
+	if node == nil {
+		return
+	}
+	w.Write([]byte{byte(node.Kind())})
+
+	switch n := node.(type) {
+	case *ObjectNode:
+		keys := n.Keys()
+		slices.Sort(keys)
+		hashInt(w, len(keys))
+		for _, key := range keys {
+			hashString(w, key)
+			value, _ := n.Value(key)
+			hashNode(w, value)
+		}
+	case *ArrayNode:
+		hashInt(w, len(n.values))
+		for _, value := range n.values {
+			hashNode(w, value)
+		}
+	case *StringNode:
+		hashString(w, n.value)
+	case *NumberNode:
+		hashString(w, canonical(n))
+	case *BooleanNode:
+		hashString(w, strconv.FormatBool(n.value))
+	case *InfinityNode:
+		hashString(w, n.String())
+	}
+}
+
+func hashInt(w io.Writer, i int) {
+	w.Write(binary.AppendUvarint(nil, uint64(i)))
+}
+
+// hashString writes a length-prefixed string so that adjacent strings can't
+// run together.
+func hashString(w io.Writer, s string) {
+	hashInt(w, len(s))
+	io.WriteString(w, s)
+}
@@ -0,0 +1,77 @@
+package ast
+
+import (
+	"testing"
+)
+
+func TestEqual(t *testing.T) {
+	tests := []struct {
+		a, b  string
+		opts  []EqualOption
+		equal bool
+	}{
+		{`{a: 0x10, b: 'x'}`, `{"b": "x", "a": 16}`, nil, true},
+		{`[1.5e1, -0, .5]`, `[15, 0, 5e-1]`, nil, true},
+		{`{a: 1, /* c */ b: [true, null]}`, `{a: 1.0, b: [true, null,]}`, nil, true},
+		{`{a: 1, a: 2}`, `{a: 2}`, nil, true},
+		{`[NaN, -Infinity]`, `[NaN, -Infinity]`, nil, true},
+		{`[Infinity]`, `[-Infinity]`, nil, false},
+		{`{a: 1}`, `{a: 1, b: 2}`, nil, false},
+		{`{a: 1}`, `{a: '1'}`, nil, false},
+		{`[1, 2]`, `[2, 1]`, nil, false},
+		{`{a: 1, b: 2}`, `{b: 2, a: 1}`, []EqualOption{OrderedKeys()}, false},
+		{`{a: 1, b: 2}`, `{a: 1, b: 2}`, []EqualOption{OrderedKeys()}, true},
+		{`[1, 2, 2]`, `[2, 1, 2]`, []EqualOption{UnorderedArrays()}, true},
+		{`[1, 2, 2]`, `[2, 1, 1]`, []EqualOption{UnorderedArrays()}, false},
+		{`0.1`, `0.1000001`, nil, false},
+		{`0.1`, `0.1000001`, []EqualOption{Tolerance(1e-6)}, true},
+		{`0.1`, `0.2`, []EqualOption{Tolerance(1e-6)}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.a+" "+tt.b, func(t *testing.T) {
+			a, err := Parse(tt.a)
+			if err != nil {
+				t.Fatalf("returned unexpected error %v", err)
+			}
+			b, err := Parse(tt.b)
+			if err != nil {
+				t.Fatalf("returned unexpected error %v", err)
+			}
+			if Equal(a, b, tt.opts...) != tt.equal {
+				t.Errorf("expected Equal to be %v", tt.equal)
+			}
+			if Equal(b, a, tt.opts...) != tt.equal {
+				t.Errorf("expected Equal to be symmetric")
+			}
+			if tt.equal && len(tt.opts) == 0 && Hash(a) != Hash(b) {
+				t.Errorf("expected equal trees to have equal hashes")
+			}
+		})
+	}
+}
+
+func TestHash(t *testing.T) {
+	hashes := make(map[uint64]string)
+	for _, source := range []string{
+		`{}`, `[]`, `null`, `0`, `1`, `"1"`, `true`, `false`, `NaN`, `Infinity`, `-Infinity`,
+		`{a: 1}`, `{a: [1]}`, `[{a: 1}]`, `['ab', 'c']`, `['a', 'bc']`, `[[], []]`, `[[[]]]`,
+	} {
+		node, err := Parse(source)
+		if err != nil {
+			t.Fatalf("returned unexpected error %v", err)
+		}
+		h := Hash(node)
+		if other, ok := hashes[h]; ok {
+			t.Errorf("hash collision between %s and %s", source, other)
+		}
+		hashes[h] = source
+	}
+
+	// The hash is part of the API for caching, so it must not change between
+	// releases.
+	node, _ := Parse(`{b: [1, 'two'], a: 0x10}`)
+	if h := Hash(node); h != 0x5eac87a819c2d7d2 {
+		t.Errorf("expected hash 0x5eac87a819c2d7d2, got %#x", h)
+	}
+}
@@ -0,0 +1,316 @@
+package ast
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// maxExponent bounds the decimal exponent accepted by the arbitrary-precision
+// conversions, so that a short literal such as 1e999999999 cannot be used to
+// allocate an enormous number.
+const maxExponent = 10000
+
+// exponentLimit is where parsed exponents saturate. A literal with a larger
+// exponent is already far out of range of float64 and maxExponent, so it is
+// still treated as infinite or zero rather than as invalid.
+const exponentLimit = 1 << 30
+
+var errNotInteger = errors.New("not an integer")
+
+// NumberNode is a finite JSON5 number in any of its spellings: decimal or
+// hexadecimal, with an optional sign, leading or trailing decimal point, and
+// exponent. The raw spelling is preserved for round-tripping.
+type NumberNode struct {
+	raw string
+	Position
+}
+
+func (n *NumberNode) Kind() Kind {
+	return NUMBER
+}
+
+// Raw returns the number exactly as it was written.
+func (n *NumberNode) Raw() string {
+	return n.raw
+}
+
+func (n *NumberNode) String() string {
+	return n.raw
+}
+
+func (n *NumberNode) IsHex() bool {
+	_, hex := n.parse()
+	return hex != ""
+}
+
+func (n *NumberNode) IsNegative() bool {
+	return strings.HasPrefix(n.raw, "-")
+}
+
+// IsInteger reports whether the number has no fractional part, regardless of
+// spelling. For example, 0x10, 1e3 and 2.0 are all integers.
+func (n *NumberNode) IsInteger() bool {
+	d, hex := n.parse()
+	return hex != "" || d.isInteger()
+}
+
+func (n *NumberNode) Int() (int, error) {
+	i, err := n.Int64()
+	if err != nil {
+		return 0, n.numError("Int", err)
+	}
+	if strconv.IntSize == 32 && (i < math.MinInt32 || i > math.MaxInt32) {
+		return 0, n.numError("Int", strconv.ErrRange)
+	}
+	return int(i), nil
+}
+
+func (n *NumberNode) Int64() (int64, error) {
+	i, err := n.bigIntWithin(19)
+	if err != nil {
+		return 0, n.numError("Int64", err)
+	}
+	if !i.IsInt64() {
+		return 0, n.numError("Int64", strconv.ErrRange)
+	}
+	return i.Int64(), nil
+}
+
+func (n *NumberNode) Uint64() (uint64, error) {
+	i, err := n.bigIntWithin(20)
+	if err != nil {
+		return 0, n.numError("Uint64", err)
+	}
+	if !i.IsUint64() {
+		return 0, n.numError("Uint64", strconv.ErrRange)
+	}
+	return i.Uint64(), nil
+}
+
+func (n *NumberNode) Float64() (float64, error) {
+	d, hex := n.parse()
+	if hex != "" {
+		i, err := n.BigInt()
+		if err != nil {
+			return 0, n.numError("Float64", err)
+		}
+		f, _ := new(big.Float).SetInt(i).Float64()
+		if math.IsInf(f, 0) {
+			return f, n.numError("Float64", strconv.ErrRange)
+		}
+		return f, nil
+	}
+	if !d.valid {
+		return 0, n.numError("Float64", strconv.ErrSyntax)
+	}
+	return strconv.ParseFloat(n.raw, 64)
+}
+
+func (n *NumberNode) BigInt() (*big.Int, error) {
+	i, err := n.bigIntWithin(maxExponent)
+	if err != nil {
+		return nil, n.numError("BigInt", err)
+	}
+	return i, nil
+}
+
+// BigFloat returns the number as a big.Float with enough precision to hold
+// every significant digit that was written.
+func (n *NumberNode) BigFloat() (*big.Float, error) {
+	r, err := n.Rat()
+	if err != nil {
+		return nil, n.numError("BigFloat", err)
+	}
+	// Four bits per digit is enough for both decimal and hexadecimal digits
+	d, hex := n.parse()
+	prec := max(64, uint(4*(len(d.digits)+len(hex))))
+	return new(big.Float).SetPrec(prec).SetRat(r), nil
+}
+
+func (n *NumberNode) Rat() (*big.Rat, error) {
+	d, hex := n.parse()
+	if hex != "" {
+		i, err := n.BigInt()
+		if err != nil {
+			return nil, err
+		}
+		return new(big.Rat).SetInt(i), nil
+	}
+	if !d.valid {
+		return nil, n.numError("Rat", strconv.ErrSyntax)
+	}
+	if d.exp > maxExponent || d.exp < -maxExponent {
+		return nil, n.numError("Rat", strconv.ErrRange)
+	}
+
+	num, _ := new(big.Int).SetString("0"+d.digits, 10)
+	if d.neg {
+		num.Neg(num)
+	}
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(abs(d.exp))), nil)
+	if d.exp >= 0 {
+		return new(big.Rat).SetInt(num.Mul(num, scale)), nil
+	}
+	return new(big.Rat).SetFrac(num, scale), nil
+}
+
+// Value returns the number as the narrowest natural Go type: int64 or uint64
+// for integers that fit, *big.Int for larger integers, and float64 otherwise.
+func (n *NumberNode) Value() (any, error) {
+	if !n.IsInteger() {
+		return n.Float64()
+	}
+	if i, err := n.Int64(); err == nil {
+		return i, nil
+	}
+	if u, err := n.Uint64(); err == nil {
+		return u, nil
+	}
+	return n.BigInt()
+}
+
+func (n *NumberNode) valid() bool {
+	d, hex := n.parse()
+	if hex != "" {
+		for i := 0; i < len(hex); i++ {
+			if !strings.ContainsRune("0123456789abcdefABCDEF", rune(hex[i])) {
+				return false
+			}
+		}
+		return true
+	}
+	return d.valid
+}
+
+// bigIntWithin converts the number to a big.Int, failing with ErrRange if it
+// would need more than maxDigits decimal digits.
+func (n *NumberNode) bigIntWithin(maxDigits int) (*big.Int, error) {
+	d, hex := n.parse()
+	if hex != "" {
+		if len(strings.TrimLeft(hex, "0")) > maxDigits {
+			return nil, strconv.ErrRange
+		}
+		i, ok := new(big.Int).SetString(hex, 16)
+		if !ok {
+			return nil, strconv.ErrSyntax
+		}
+		if n.IsNegative() {
+			i.Neg(i)
+		}
+		return i, nil
+	}
+
+	if !d.valid {
+		return nil, strconv.ErrSyntax
+	}
+	if !d.isInteger() {
+		return nil, errNotInteger
+	}
+	if d.digits == "" {
+		return new(big.Int), nil
+	}
+	if len(d.digits)+d.exp > maxDigits {
+		return nil, strconv.ErrRange
+	}
+
+	r, err := n.Rat()
+	if err != nil {
+		return nil, err
+	}
+	return r.Num(), nil
+}
+
+func (n *NumberNode) numError(fn string, err error) error {
+	var nerr *strconv.NumError
+	if errors.As(err, &nerr) {
+		return err
+	}
+	return &strconv.NumError{Func: fn, Num: n.raw, Err: err}
+}
+
+// parse splits the raw number into its parts. Hexadecimal numbers return their
+// digits as hex; decimal numbers return a decimal with hex empty.
+func (n *NumberNode) parse() (decimal, string) {
+	s := strings.TrimLeft(n.raw, "+-")
+	if len(n.raw)-len(s) > 1 {
+		return decimal{}, ""
+	}
+	if len(s) > 2 && s[0] == '0' && (s[1] == 'x' || s[1] == 'X') {
+		return decimal{}, s[2:]
+	}
+	return parseDecimal(n.raw), ""
+}
+
+// decimal is a decimal number in the form digits * 10^exp, with digits holding
+// no leading or trailing zeros.
+type decimal struct {
+	neg    bool
+	digits string
+	exp    int
+	valid  bool
+}
+
+func (d decimal) isInteger() bool {
+	return d.digits == "" || d.exp >= 0
+}
+
+func parseDecimal(s string) decimal {
+	var d decimal
+	if s != "" && (s[0] == '-' || s[0] == '+') {
+		d.neg = s[0] == '-'
+		s = s[1:]
+	}
+
+	mantissa, exponent, hasExp := strings.Cut(strings.ToLower(s), "e")
+	intPart, fracPart, _ := strings.Cut(mantissa, ".")
+	if intPart == "" && fracPart == "" || !isDigits(intPart) || !isDigits(fracPart) {
+		return decimal{}
+	}
+	// Like JSON, the integer part may not have leading zeros
+	if len(intPart) > 1 && intPart[0] == '0' {
+		return decimal{}
+	}
+
+	if hasExp {
+		e, err := strconv.Atoi(exponent)
+		if errors.Is(err, strconv.ErrRange) {
+			e = exponentLimit
+			if strings.HasPrefix(exponent, "-") {
+				e = -exponentLimit
+			}
+		} else if err != nil {
+			return decimal{}
+		}
+		d.exp = min(max(e, -exponentLimit), exponentLimit)
+	}
+
+	digits := strings.TrimLeft(intPart+fracPart, "0")
+	d.exp -= len(fracPart)
+	trimmed := strings.TrimRight(digits, "0")
+	d.exp += len(digits) - len(trimmed)
+	d.digits = trimmed
+	if d.digits == "" {
+		d.exp = 0
+	}
+	d.valid = true
+	return d
+}
+
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func abs(i int) int {
+	if i < 0 {
+		return -i
+	}
+	return i
+}
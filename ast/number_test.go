@@ -0,0 +1,156 @@
+package ast
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"testing"
+)
+
+func TestNumberNode(t *testing.T) {
+	tests := []struct {
+		raw     string
+		integer bool
+		hex     bool
+		value   any
+		float   float64
+	}{
+		{"0", true, false, int64(0), 0},
+		{"-0", true, false, int64(0), 0},
+		{"+1", true, false, int64(1), 1},
+		{"-42", true, false, int64(-42), -42},
+		{"0xdecaf", true, true, int64(0xdecaf), 0xdecaf},
+		{"-0XFF", true, true, int64(-255), -255},
+		{"1e3", true, false, int64(1000), 1000},
+		{"2.0", true, false, int64(2), 2},
+		{"2.50E1", true, false, int64(25), 25},
+		{".5", false, false, 0.5, 0.5},
+		{"5.", true, false, int64(5), 5},
+		{"-.25e-2", false, false, -0.0025, -0.0025},
+		{"18446744073709551615", true, false, uint64(18446744073709551615), 18446744073709551615},
+		{"0x10000000000000000", true, true, new(big.Int).Lsh(big.NewInt(1), 64), 18446744073709551616},
+		{"123456789012345678901234567890", true, false, bigInt("123456789012345678901234567890"), 123456789012345678901234567890},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			n := &NumberNode{raw: tt.raw}
+			if !n.valid() {
+				t.Fatalf("expected %q to be valid", tt.raw)
+			}
+			if n.IsInteger() != tt.integer {
+				t.Errorf("expected IsInteger %v, got %v", tt.integer, n.IsInteger())
+			}
+			if n.IsHex() != tt.hex {
+				t.Errorf("expected IsHex %v, got %v", tt.hex, n.IsHex())
+			}
+
+			value, err := n.Value()
+			if err != nil {
+				t.Fatalf("returned unexpected error %v", err)
+			}
+			if fmt.Sprintf("%T %v", value, value) != fmt.Sprintf("%T %v", tt.value, tt.value) {
+				t.Errorf("expected value %T %v, got %T %v", tt.value, tt.value, value, value)
+			}
+
+			f, err := n.Float64()
+			if err != nil {
+				t.Fatalf("returned unexpected error %v", err)
+			}
+			if f != tt.float {
+				t.Errorf("expected float %v, got %v", tt.float, f)
+			}
+
+			if n.String() != tt.raw {
+				t.Errorf("expected raw %q, got %q", tt.raw, n.String())
+			}
+		})
+	}
+}
+
+func TestNumberNode_Conversions(t *testing.T) {
+	n := &NumberNode{raw: "-1.5e-1"}
+
+	r, err := n.Rat()
+	if err != nil {
+		t.Fatalf("returned unexpected error %v", err)
+	}
+	if r.String() != "-3/20" {
+		t.Errorf("expected rat -3/20, got %s", r)
+	}
+
+	f, err := n.BigFloat()
+	if err != nil {
+		t.Fatalf("returned unexpected error %v", err)
+	}
+	if f.Text('g', 10) != "-0.15" {
+		t.Errorf("expected big float -0.15, got %s", f.Text('g', 10))
+	}
+
+	if _, err := n.Int64(); !errors.Is(err, errNotInteger) {
+		t.Errorf("expected not an integer error, got %v", err)
+	}
+	if _, err := (&NumberNode{raw: "-1"}).Uint64(); !errors.Is(err, strconv.ErrRange) {
+		t.Errorf("expected range error, got %v", err)
+	}
+	if _, err := (&NumberNode{raw: "9223372036854775808"}).Int64(); !errors.Is(err, strconv.ErrRange) {
+		t.Errorf("expected range error, got %v", err)
+	}
+	if _, err := (&NumberNode{raw: "1e999999999"}).BigInt(); !errors.Is(err, strconv.ErrRange) {
+		t.Errorf("expected range error, got %v", err)
+	}
+
+	var nerr *strconv.NumError
+	if _, err := (&NumberNode{raw: "1.5"}).Int(); !errors.As(err, &nerr) || nerr.Num != "1.5" {
+		t.Errorf("expected *strconv.NumError for %q, got %v", "1.5", err)
+	}
+}
+
+func TestNumberNode_Invalid(t *testing.T) {
+	for _, raw := range []string{"", "-", ".", "1e", "1e+", "0x", "0xZ", "--1", "1.2.3", "e5", "007", "-01", "00.5"} {
+		t.Run(fmt.Sprintf("%q", raw), func(t *testing.T) {
+			if (&NumberNode{raw: raw}).valid() {
+				t.Errorf("expected %q to be invalid", raw)
+			}
+		})
+	}
+
+	if _, err := Parse("[1, 2e]"); err == nil {
+		t.Errorf("expected parse error for invalid number")
+	}
+}
+
+func TestNumberNode_HugeExponent(t *testing.T) {
+	tests := []struct {
+		raw   string
+		float float64
+	}{
+		{"1e99999999999999999999", math.Inf(1)},
+		{"-1.5E+99999999999999999999", math.Inf(-1)},
+		{"1e-99999999999999999999", 0},
+		{"0e99999999999999999999", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			n, err := ParseNumber(tt.raw)
+			if err != nil {
+				t.Fatalf("returned unexpected error %v", err)
+			}
+			if f, _ := n.Float64(); f != tt.float {
+				t.Errorf("expected float %v, got %v", tt.float, f)
+			}
+		})
+	}
+
+	if _, err := (&NumberNode{raw: "1e99999999999999999999"}).BigInt(); !errors.Is(err, strconv.ErrRange) {
+		t.Errorf("expected range error, got %v", err)
+	}
+}
+
+func bigInt(s string) *big.Int {
+	i, _ := new(big.Int).SetString(s, 10)
+	return i
+}
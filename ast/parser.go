@@ -0,0 +1,323 @@
+package ast
+
+import (
+	"fmt"
+
+	"github.com/Roundaround/json5-go/annotate"
+	"github.com/Roundaround/json5-go/lexer"
+	"github.com/Roundaround/json5-go/path"
+	"github.com/Roundaround/json5-go/token"
+)
+
+type ParseOption func(*parser)
+
+// DuplicateKeys sets how the parser handles keys that occur more than once in
+// the same object. The default is LastWins.
+func DuplicateKeys(policy DuplicateKeyPolicy) ParseOption {
+	return func(p *parser) {
+		p.duplicates = policy
+	}
+}
+
+// SourceName records the name of the file or URI being parsed on every node,
+// so that nodes combined from several documents can be traced back to theirs.
+func SourceName(name string) ParseOption {
+	return func(p *parser) {
+		p.name = name
+	}
+}
+
+func Parse(source string, opts ...ParseOption) (Node, error) {
+	p := newParser(source, opts...)
+
+	node, err := p.parseValue()
+	if err != nil {
+		return nil, err
+	}
+	if p.tok.Kind != token.EOF {
+		return nil, p.errf("expected end of input, got %s", describe(p.tok))
+	}
+	AddTrailingComments(node, p.takeComments()...)
+	return node, nil
+}
+
+func newParser(source string, opts ...ParseOption) *parser {
+	p := &parser{source: source}
+	for _, opt := range opts {
+		opt(p)
+	}
+	p.lexer = lexer.New(source, lexer.SourceName(p.name))
+	p.advance()
+	return p
+}
+
+type parser struct {
+	source     string
+	lexer      *lexer.Lexer
+	tok        token.Token
+	comments   []*CommentNode
+	duplicates DuplicateKeyPolicy
+	name       string
+}
+
+// advance moves to the next significant token, queueing any comments along
+// the way so they can be attached to a neighboring node.
+func (p *parser) advance() {
+	p.tok = p.lexer.NextToken()
+	for p.tok.Kind.IsComment() {
+		comment := Comment(p.tok.Literal)
+		comment.Position = p.position()
+		p.comments = append(p.comments, comment)
+		p.tok = p.lexer.NextToken()
+	}
+}
+
+func (p *parser) takeComments() []*CommentNode {
+	comments := p.comments
+	p.comments = nil
+	return comments
+}
+
+// attachTrailing attaches the queued comments that begin on the same line
+// that node ends on.
+func (p *parser) attachTrailing(node Node) {
+	i := 0
+	for i < len(p.comments) && p.comments[i].line == node.End().Line {
+		i++
+	}
+	AddTrailingComments(node, p.comments[:i]...)
+	p.comments = p.comments[i:]
+}
+
+func (p *parser) expect(kind token.Kind) error {
+	if p.tok.Kind != kind {
+		return p.errf("expected %s, got %s", kind, describe(p.tok))
+	}
+	p.advance()
+	return nil
+}
+
+// parseValue parses a value, attaching any queued comments to it as leading
+// comments.
+func (p *parser) parseValue() (Node, error) {
+	leading := p.takeComments()
+	node, err := p.parseBareValue()
+	if err != nil {
+		return nil, err
+	}
+	AddLeadingComments(node, leading...)
+	return node, nil
+}
+
+func (p *parser) parseBareValue() (Node, error) {
+	switch p.tok.Kind {
+	case token.LEFT_BRACE:
+		return p.parseObject()
+	case token.LEFT_BRACKET:
+		return p.parseArray()
+	case token.QUOTED_STRING:
+		node := String(p.tok.Literal)
+		node.Position = p.position()
+		p.advance()
+		return node, nil
+	case token.DECIMAL_NUMBER, token.HEX_NUMBER:
+		node := &NumberNode{raw: p.tok.Literal, Position: p.position()}
+		if !node.valid() {
+			return nil, p.errf("invalid number %q", p.tok.Literal)
+		}
+		p.advance()
+		return node, nil
+	case token.INFINITY:
+		node := &InfinityNode{negative: p.tok.Literal[0] == '-', Position: p.position()}
+		p.advance()
+		return node, nil
+	case token.NAN:
+		node := &NaNNode{Position: p.position()}
+		p.advance()
+		return node, nil
+	case token.BOOLEAN:
+		node := &BooleanNode{value: p.tok.Literal == "true", Position: p.position()}
+		p.advance()
+		return node, nil
+	case token.NULL:
+		node := &NullNode{Position: p.position()}
+		p.advance()
+		return node, nil
+	default:
+		return nil, p.errf("expected value, got %s", describe(p.tok))
+	}
+}
+
+func (p *parser) parseObject() (*ObjectNode, error) {
+	node := &ObjectNode{Position: p.position()}
+	p.advance() // skip '{'
+
+	for p.tok.Kind != token.RIGHT_BRACE {
+		member, err := p.parseMember()
+		if err != nil {
+			return nil, err
+		}
+		member.parent = node
+		if err := node.add(member, p.duplicates); err != nil {
+			return nil, p.errat(member.Position, err)
+		}
+
+		more := p.tok.Kind == token.COMMA
+		if more {
+			p.advance() // skip ','
+		}
+		p.attachTrailing(member.value)
+		if !more {
+			break
+		}
+	}
+
+	node.dangling = p.takeComments()
+	for _, c := range node.dangling {
+		c.parent = node
+	}
+	node.end = p.tok.End
+	if err := p.expect(token.RIGHT_BRACE); err != nil {
+		return nil, err
+	}
+	return node, nil
+}
+
+func (p *parser) parseMember() (*Member, error) {
+	var key string
+	switch p.tok.Kind {
+	case token.QUOTED_STRING:
+		key = String(p.tok.Literal).Value()
+	case token.UNQUOTED_STRING, token.BOOLEAN, token.NULL, token.INFINITY, token.NAN:
+		key = p.tok.Literal
+	default:
+		return nil, p.errf("expected key, got %s", describe(p.tok))
+	}
+
+	member := &Member{key: key, Position: p.position()}
+	member.segment = path.Key(key)
+	p.advance()
+
+	if err := p.expect(token.COLON); err != nil {
+		return nil, err
+	}
+
+	value, err := p.parseValue()
+	if err != nil {
+		return nil, err
+	}
+	member.value = value
+
+	return member, nil
+}
+
+func (p *parser) parseArray() (*ArrayNode, error) {
+	node := &ArrayNode{values: make([]Node, 0), Position: p.position()}
+	p.advance() // skip '['
+
+	for p.tok.Kind != token.RIGHT_BRACKET {
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		attach(value, node, path.Index(len(node.values)))
+		node.values = append(node.values, value)
+
+		more := p.tok.Kind == token.COMMA
+		if more {
+			p.advance() // skip ','
+		}
+		p.attachTrailing(value)
+		if !more {
+			break
+		}
+	}
+
+	node.dangling = p.takeComments()
+	for _, c := range node.dangling {
+		c.parent = node
+	}
+	node.end = p.tok.End
+	if err := p.expect(token.RIGHT_BRACKET); err != nil {
+		return nil, err
+	}
+	return node, nil
+}
+
+// position returns the position of the current token. Containers overwrite
+// the end once their closing token is reached.
+func (p *parser) position() Position {
+	return Position{
+		offset: p.tok.Offset,
+		line:   p.tok.Line,
+		column: p.tok.Column,
+		end:    p.tok.End,
+		source: p.tok.Source,
+	}
+}
+
+func (p *parser) errf(format string, args ...any) *ParseError {
+	return p.errat(p.position(), fmt.Errorf(format, args...))
+}
+
+func (p *parser) errat(pos Position, err error) *ParseError {
+	return &ParseError{err, pos.offset, pos.line, pos.column, p.source, p.name}
+}
+
+type ParseError struct {
+	err    error
+	offset int
+	line   int
+	column int
+	source string
+	name   string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("invalid json5 %s: %v", e.location(), e.err)
+}
+
+func (e *ParseError) Unwrap() []error {
+	return []error{e.err}
+}
+
+func (e *ParseError) Offset() int {
+	return e.offset
+}
+
+func (e *ParseError) Line() int {
+	return e.line
+}
+
+func (e *ParseError) Column() int {
+	return e.column
+}
+
+// SourceName returns the name given to SourceName when parsing, if any.
+func (e *ParseError) SourceName() string {
+	return e.name
+}
+
+func (e *ParseError) location() string {
+	if e.name != "" {
+		return fmt.Sprintf("in %s at ln %d, col %d", e.name, e.line, e.column)
+	}
+	return fmt.Sprintf("at ln %d, col %d", e.line, e.column)
+}
+
+// Annotate renders the error beneath the offending line of source, with a
+// caret marking where it occurred.
+func (e *ParseError) Annotate() string {
+	return fmt.Sprintf("invalid json5 %s:\n%s", e.location(), annotate.Line(e.source, e.offset, e.err.Error()))
+}
+
+func describe(tok token.Token) string {
+	switch tok.Kind {
+	case token.EOF:
+		return "end of input"
+	case token.ILLEGAL:
+		return fmt.Sprintf("illegal token %q", tok.Raw)
+	default:
+		return fmt.Sprintf("%s %q", tok.Kind, tok.Raw)
+	}
+}
@@ -0,0 +1,363 @@
+package ast
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"testing"
+
+	"github.com/Roundaround/json5-go/path"
+)
+
+func TestParse_ObjectOrder(t *testing.T) {
+	source := `{
+  zebra: 1,
+  'apple': 2,
+  "mango": 3,
+  null: 4,
+}`
+
+	node, err := Parse(source)
+	if err != nil {
+		t.Fatalf("returned unexpected error %v", err)
+	}
+
+	obj, ok := node.(*ObjectNode)
+	if !ok {
+		t.Fatalf("expected *ObjectNode, got %T", node)
+	}
+
+	want := []string{"zebra", "apple", "mango", "null"}
+	if keys := obj.Keys(); !slices.Equal(keys, want) {
+		t.Errorf("expected keys %v, got %v", want, keys)
+	}
+
+	i := 0
+	for key := range obj.All() {
+		if key != want[i] {
+			t.Errorf("All: expected key %q at %d, got %q", want[i], i, key)
+		}
+		i++
+	}
+
+	m, ok := obj.Member("mango")
+	if !ok {
+		t.Fatalf("expected member %q", "mango")
+	}
+	if m.Line() != 4 || m.Column() != 3 {
+		t.Errorf("expected key at ln 4, col 3, got ln %d, col %d", m.Line(), m.Column())
+	}
+	if segment := m.Segment(); segment != path.Key("mango") {
+		t.Errorf("expected segment %q, got %q", "mango", segment.String())
+	}
+}
+
+func TestParse_DuplicateKeys(t *testing.T) {
+	source := "{a: 1, b: 2, a: 3}"
+
+	tests := []struct {
+		policy     DuplicateKeyPolicy
+		keys       []string
+		value      string
+		duplicates int
+	}{
+		{LastWins, []string{"a", "b"}, "3", 1},
+		{FirstWins, []string{"a", "b"}, "1", 1},
+		{CollectDuplicates, []string{"a", "b"}, "3", 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.policy.String(), func(t *testing.T) {
+			node, err := Parse(source, DuplicateKeys(tt.policy))
+			if err != nil {
+				t.Fatalf("returned unexpected error %v", err)
+			}
+			obj := node.(*ObjectNode)
+
+			if keys := obj.Keys(); !slices.Equal(keys, tt.keys) {
+				t.Errorf("expected keys %v, got %v", tt.keys, keys)
+			}
+
+			value, ok := obj.Value("a")
+			if !ok {
+				t.Fatalf("expected value for key %q", "a")
+			}
+			if got := value.(*NumberNode).String(); got != tt.value {
+				t.Errorf("expected value %s, got %s", tt.value, got)
+			}
+
+			if len(obj.Duplicates()) != tt.duplicates {
+				t.Errorf("expected %d duplicates, got %d", tt.duplicates, len(obj.Duplicates()))
+			}
+			if value.Parent() != obj {
+				t.Errorf("expected kept value to belong to the object")
+			}
+		})
+	}
+
+	t.Run("shadowed values are detached", func(t *testing.T) {
+		for _, policy := range []DuplicateKeyPolicy{LastWins, FirstWins} {
+			node, _ := Parse(source, DuplicateKeys(policy))
+			for _, m := range node.(*ObjectNode).Duplicates() {
+				if m.Value().Parent() != nil {
+					t.Errorf("%s: expected shadowed value %s to have no parent, got path %q", policy, m.Value(), m.Value().Path())
+				}
+			}
+			if c := Clone(node).(*ObjectNode); c.Duplicates()[0].Value().Parent() != nil {
+				t.Errorf("%s: expected cloned shadowed value to have no parent", policy)
+			}
+		}
+	})
+
+	t.Run("Collect keeps every member", func(t *testing.T) {
+		node, _ := Parse(source, DuplicateKeys(CollectDuplicates))
+		if n := node.(*ObjectNode).Len(); n != 3 {
+			t.Errorf("expected 3 members, got %d", n)
+		}
+	})
+
+	t.Run(ErrorOnDuplicate.String(), func(t *testing.T) {
+		_, err := Parse(source, DuplicateKeys(ErrorOnDuplicate))
+
+		var derr *DuplicateKeyError
+		if !errors.As(err, &derr) {
+			t.Fatalf("expected *DuplicateKeyError, got %v", err)
+		}
+		if derr.Key != "a" {
+			t.Errorf("expected duplicate key %q, got %q", "a", derr.Key)
+		}
+		if derr.Duplicate.Column() != 14 {
+			t.Errorf("expected duplicate at col 14, got col %d", derr.Duplicate.Column())
+		}
+	})
+}
+
+func TestParse_Errors(t *testing.T) {
+	tests := []struct {
+		source string
+		line   int
+		column int
+		msg    string
+	}{
+		{"", 1, 1, "expected value, got end of input"},
+		{"{a 1}", 1, 4, "expected Colon, got Decimal Number \"1\""},
+		{"[1, 2", 1, 6, "expected Right Bracket, got end of input"},
+		{"{\n  a: unquoted\n}", 2, 6, "expected value, got Unquoted String \"unquoted\""},
+		{"[1] 2", 1, 5, "expected end of input, got Decimal Number \"2\""},
+		{"{'a: 1}", 1, 2, "expected key, got illegal token \"'a: 1}\""},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%q", tt.source), func(t *testing.T) {
+			_, err := Parse(tt.source)
+
+			var perr *ParseError
+			if !errors.As(err, &perr) {
+				t.Fatalf("expected *ParseError, got %v", err)
+			}
+			if perr.Line() != tt.line || perr.Column() != tt.column {
+				t.Errorf("expected error at ln %d, col %d, got ln %d, col %d", tt.line, tt.column, perr.Line(), perr.Column())
+			}
+			if perr.err.Error() != tt.msg {
+				t.Errorf("expected error %q, got %q", tt.msg, perr.err.Error())
+			}
+		})
+	}
+}
+
+func TestParseError_Annotate(t *testing.T) {
+	_, err := Parse("{\n\ta: 1,\n\tb 2,\n}")
+
+	var perr *ParseError
+	if !errors.As(err, &perr) {
+		t.Fatalf("expected *ParseError, got %v", err)
+	}
+
+	want := "invalid json5 at ln 3, col 4:\n\tb 2,\n\t  ^ expected Colon, got Decimal Number \"2\""
+	if got := perr.Annotate(); got != want {
+		t.Errorf("expected:\n%s\ngot:\n%s", want, got)
+	}
+}
+
+func TestParse_Positions(t *testing.T) {
+	source := `{
+  servers: [
+    { host: 'a', port: 80 },
+    { host: 'b', tags: [true, null] },
+  ],
+}`
+
+	root, err := Parse(source)
+	if err != nil {
+		t.Fatalf("returned unexpected error %v", err)
+	}
+
+	servers, _ := root.(*ObjectNode).Value("servers")
+	second, _ := servers.(*ArrayNode).Value(1)
+	tags, _ := second.(*ObjectNode).Value("tags")
+	null, _ := tags.(*ArrayNode).Value(1)
+
+	tests := []struct {
+		node   Node
+		path   string
+		parent Node
+		span   string
+	}{
+		{root, "", nil, "1:1-6:2"},
+		{servers, "servers", root, "2:12-5:4"},
+		{second, "servers[1]", servers, "4:5-4:38"},
+		{tags, "servers[1].tags", second, "4:24-4:36"},
+		{null, "servers[1].tags[1]", tags, "4:31-4:35"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := tt.node.Path().String(); got != tt.path {
+				t.Errorf("expected path %q, got %q", tt.path, got)
+			}
+			if tt.node.Parent() != tt.parent {
+				t.Errorf("expected parent %v, got %v", tt.parent, tt.node.Parent())
+			}
+			if got := tt.node.Span().String(); got != tt.span {
+				t.Errorf("expected span %s, got %s", tt.span, got)
+			}
+		})
+	}
+
+	if got := source[null.Start().Offset:null.End().Offset]; got != "null" {
+		t.Errorf("expected span to cover %q, got %q", "null", got)
+	}
+}
+
+func TestParseError_SourceName(t *testing.T) {
+	_, err := Parse("{\n  a 1,\n}", SourceName("app.json5"))
+
+	var perr *ParseError
+	if !errors.As(err, &perr) {
+		t.Fatalf("expected *ParseError, got %v", err)
+	}
+	if perr.SourceName() != "app.json5" {
+		t.Errorf("expected source name app.json5, got %q", perr.SourceName())
+	}
+	want := `invalid json5 in app.json5 at ln 2, col 5: expected Colon, got Decimal Number "1"`
+	if perr.Error() != want {
+		t.Errorf("expected %q, got %q", want, perr.Error())
+	}
+}
+
+func TestParse_SourceName(t *testing.T) {
+	root, err := Parse("{a: [1], // one\n}", SourceName("base.json5"))
+	if err != nil {
+		t.Fatalf("returned unexpected error %v", err)
+	}
+
+	for p, node := range Preorder(root) {
+		if node.Source() != "base.json5" {
+			t.Errorf("%q: expected source base.json5, got %q", p, node.Source())
+		}
+	}
+	a, _ := root.(*ObjectNode).Value("a")
+	if got := a.Span().String(); got != "base.json5:1:5-1:8" {
+		t.Errorf("expected span base.json5:1:5-1:8, got %s", got)
+	}
+	if c := a.TrailingComments()[0]; c.Source() != "base.json5" {
+		t.Errorf("expected comment source base.json5, got %q", c.Source())
+	}
+	if Clone(a).Source() != "base.json5" {
+		t.Errorf("expected clone to keep its source")
+	}
+}
+
+func TestParse_Comments(t *testing.T) {
+	source := `// leading root
+{
+  // leading a
+  a: 1, // trailing a
+  b: /* inline b */ [
+    2, /* trailing 2 */
+    // dangling array
+  ],
+  /* dangling object */
+} // trailing root`
+
+	root, err := Parse(source)
+	if err != nil {
+		t.Fatalf("returned unexpected error %v", err)
+	}
+	obj := root.(*ObjectNode)
+	a, _ := obj.Value("a")
+	b, _ := obj.Value("b")
+	two, _ := b.(*ArrayNode).Value(0)
+
+	texts := func(comments []*CommentNode) []string {
+		result := make([]string, 0, len(comments))
+		for _, c := range comments {
+			result = append(result, c.String())
+		}
+		return result
+	}
+
+	tests := []struct {
+		name string
+		got  []*CommentNode
+		want []string
+	}{
+		{"root leading", root.LeadingComments(), []string{"// leading root"}},
+		{"root trailing", root.TrailingComments(), []string{"// trailing root"}},
+		{"a leading", a.LeadingComments(), []string{"// leading a"}},
+		{"a trailing", a.TrailingComments(), []string{"// trailing a"}},
+		{"b leading", b.LeadingComments(), []string{"/* inline b */"}},
+		{"2 trailing", two.TrailingComments(), []string{"/* trailing 2 */"}},
+		{"array dangling", b.(*ArrayNode).DanglingComments(), []string{"// dangling array"}},
+		{"object dangling", obj.DanglingComments(), []string{"/* dangling object */"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := texts(tt.got); !slices.Equal(got, tt.want) {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+
+	comment := a.LeadingComments()[0]
+	if comment.IsBlock() || comment.Text() != " leading a" {
+		t.Errorf("expected line comment %q, got %q", " leading a", comment.Text())
+	}
+	if comment.Line() != 3 || comment.Column() != 3 {
+		t.Errorf("expected comment at ln 3, col 3, got ln %d, col %d", comment.Line(), comment.Column())
+	}
+	if comment.Parent() != a || comment.Path().String() != "a" {
+		t.Errorf("expected comment attached to %q, got %q", "a", comment.Path().String())
+	}
+}
+
+func TestParse_InfinityAndNaN(t *testing.T) {
+	root, err := Parse("[Infinity, -Infinity, +Infinity, NaN, -NaN]")
+	if err != nil {
+		t.Fatalf("returned unexpected error %v", err)
+	}
+	values := root.(*ArrayNode).Values()
+
+	signs := []int{1, -1, 1}
+	for i, sign := range signs {
+		inf, ok := values[i].(*InfinityNode)
+		if !ok {
+			t.Fatalf("%d: expected *InfinityNode, got %T", i, values[i])
+		}
+		if inf.Sign() != sign || !math.IsInf(inf.Value(), sign) {
+			t.Errorf("%d: expected sign %d, got %d", i, sign, inf.Sign())
+		}
+	}
+
+	for _, value := range values[3:] {
+		nan, ok := value.(*NaNNode)
+		if !ok {
+			t.Fatalf("expected *NaNNode, got %T", value)
+		}
+		if !math.IsNaN(nan.Value()) {
+			t.Errorf("expected NaN, got %v", nan.Value())
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package ast
+
+import (
+	"iter"
+
+	"github.com/Roundaround/json5-go/path"
+)
+
+// A Visitor's Visit method is invoked for each node encountered by Walk. If the
+// result visitor w is not nil, Walk visits each of the children of node with
+// the visitor w, followed by a call of w.Visit(nil).
+type Visitor interface {
+	Visit(node Node) (w Visitor)
+}
+
+// Walk traverses an AST in depth-first order: it starts by calling
+// v.Visit(node); node must not be nil. If the visitor w returned by
+// v.Visit(node) is not nil, Walk is invoked recursively with visitor w for each
+// of the non-nil children of node, followed by a call of w.Visit(nil).
+//
+// Object members are visited in source order. Comments are not part of the
+// value tree and are not visited.
+func Walk(v Visitor, node Node) {
+	if v = v.Visit(node); v == nil {
+		return
+	}
+
+	for _, child := range children(node) {
+		Walk(v, child)
+	}
+
+	v.Visit(nil)
+}
+
+// Inspect traverses an AST in depth-first order: it starts by calling
+// f(node, path), where path is the path from the root of the traversal to
+// node. If f returns true, Inspect invokes f recursively for each of the
+// children of node, followed by a call of f(nil, nil).
+func Inspect(node Node, f func(Node, *path.Path) bool) {
+	inspect(node, path.Must(), f)
+}
+
+func inspect(node Node, p *path.Path, f func(Node, *path.Path) bool) {
+	if !f(node, p.Clone()) {
+		return
+	}
+
+	for _, child := range children(node) {
+		p.Append(child.Segment())
+		inspect(child, p, f)
+		p.Pop()
+	}
+
+	f(nil, nil)
+}
+
+// Preorder returns an iterator over every node in the tree rooted at root,
+// paired with its path relative to root, in depth-first order.
+func Preorder(root Node) iter.Seq2[*path.Path, Node] {
+	return Traverse(root).All()
+}
+
+// Traversal is a depth-first iteration over a tree that can skip subtrees.
+//
+//	t := ast.Traverse(root)
+//	for p, node := range t.All() {
+//		if node.Kind() == ast.ARRAY {
+//			t.SkipChildren()
+//		}
+//	}
+type Traversal struct {
+	root Node
+	skip bool
+}
+
+func Traverse(root Node) *Traversal {
+	return &Traversal{root: root}
+}
+
+// SkipChildren prevents the traversal from descending into the children of
+// the node most recently produced by All.
+func (t *Traversal) SkipChildren() {
+	t.skip = true
+}
+
+func (t *Traversal) All() iter.Seq2[*path.Path, Node] {
+	return func(yield func(*path.Path, Node) bool) {
+		t.walk(t.root, path.Must(), yield)
+	}
+}
+
+func (t *Traversal) walk(node Node, p *path.Path, yield func(*path.Path, Node) bool) bool {
+	t.skip = false
+	if !yield(p.Clone(), node) {
+		return false
+	}
+	if t.skip {
+		t.skip = false
+		return true
+	}
+
+	for _, child := range children(node) {
+		p.Append(child.Segment())
+		ok := t.walk(child, p, yield)
+		p.Pop()
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// children returns the direct children of a node in source order.
+func children(node Node) []Node {
+	switch n := node.(type) {
+	case *ObjectNode:
+		values := make([]Node, 0, len(n.members))
+		for _, m := range n.members {
+			values = append(values, m.value)
+		}
+		return values
+	case *ArrayNode:
+		return n.values
+	default:
+		return nil
+	}
+}
+
+// Lookup returns the node at p, relative to root.
+func Lookup(root Node, p *path.Path) (Node, bool) {
+	node := root
+	for _, segment := range p.Segments() {
+		var ok bool
+		switch n := node.(type) {
+		case *ObjectNode:
+			if segment.IsIndex() {
+				return nil, false
+			}
+			node, ok = n.Value(segment.Key())
+		case *ArrayNode:
+			if !segment.IsIndex() {
+				return nil, false
+			}
+			node, ok = n.Value(segment.Index())
+		}
+		if !ok {
+			return nil, false
+		}
+	}
+	return node, true
+}
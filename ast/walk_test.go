@@ -0,0 +1,185 @@
+package ast
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/Roundaround/json5-go/path"
+)
+
+const walkSource = `{
+  name: 'app',
+  servers: [
+    { host: 'a', ports: [80, 443] },
+  ],
+  debug: false,
+}`
+
+type kindVisitor struct {
+	kinds *[]Kind
+}
+
+func (v kindVisitor) Visit(node Node) Visitor {
+	if node == nil {
+		return nil
+	}
+	*v.kinds = append(*v.kinds, node.Kind())
+	if node.Kind() == ARRAY {
+		// Don't descend into arrays
+		return nil
+	}
+	return v
+}
+
+func TestWalk(t *testing.T) {
+	root, err := Parse(walkSource)
+	if err != nil {
+		t.Fatalf("returned unexpected error %v", err)
+	}
+
+	kinds := make([]Kind, 0)
+	Walk(kindVisitor{&kinds}, root)
+
+	want := []Kind{OBJECT, STRING, ARRAY, BOOLEAN}
+	if !slices.Equal(kinds, want) {
+		t.Errorf("expected %v, got %v", want, kinds)
+	}
+}
+
+func TestInspect(t *testing.T) {
+	root, err := Parse(walkSource)
+	if err != nil {
+		t.Fatalf("returned unexpected error %v", err)
+	}
+
+	paths := make([]string, 0)
+	Inspect(root, func(node Node, p *path.Path) bool {
+		if node == nil {
+			return false
+		}
+		paths = append(paths, p.String())
+		return node.Kind() != OBJECT || p.IsEmpty()
+	})
+
+	want := []string{"", "name", "servers", "servers[0]", "debug"}
+	if !slices.Equal(paths, want) {
+		t.Errorf("expected %q, got %q", want, paths)
+	}
+}
+
+func TestPreorder(t *testing.T) {
+	root, err := Parse(walkSource)
+	if err != nil {
+		t.Fatalf("returned unexpected error %v", err)
+	}
+
+	paths := make([]string, 0)
+	for p, node := range Preorder(root) {
+		paths = append(paths, p.String())
+		if !p.Equals(node.Path()) {
+			t.Errorf("expected yielded path %q to match node path %q", p, node.Path())
+		}
+	}
+
+	want := []string{
+		"",
+		"name",
+		"servers",
+		"servers[0]",
+		"servers[0].host",
+		"servers[0].ports",
+		"servers[0].ports[0]",
+		"servers[0].ports[1]",
+		"debug",
+	}
+	if !slices.Equal(paths, want) {
+		t.Errorf("expected %q, got %q", want, paths)
+	}
+
+	t.Run("SkipChildren", func(t *testing.T) {
+		paths := make([]string, 0)
+		traversal := Traverse(root)
+		for p, node := range traversal.All() {
+			paths = append(paths, p.String())
+			if node.Kind() == ARRAY {
+				traversal.SkipChildren()
+			}
+		}
+
+		want := []string{"", "name", "servers", "debug"}
+		if !slices.Equal(paths, want) {
+			t.Errorf("expected %q, got %q", want, paths)
+		}
+	})
+
+	t.Run("break", func(t *testing.T) {
+		count := 0
+		for range Preorder(root) {
+			count++
+			if count == 3 {
+				break
+			}
+		}
+		if count != 3 {
+			t.Errorf("expected iteration to stop after 3 nodes, got %d", count)
+		}
+	})
+}
+
+func TestLookup(t *testing.T) {
+	root, err := Parse(walkSource)
+	if err != nil {
+		t.Fatalf("returned unexpected error %v", err)
+	}
+
+	tests := []struct {
+		path  *path.Path
+		kind  Kind
+		found bool
+	}{
+		{path.Must(), OBJECT, true},
+		{path.Must("servers", 0, "ports", 1), NUMBER, true},
+		{path.Must("servers", 1), UNKNOWN, false},
+		{path.Must("servers", "0"), UNKNOWN, false},
+		{path.Must("name", "x"), UNKNOWN, false},
+	}
+
+	for _, tt := range tests {
+		node, ok := Lookup(root, tt.path)
+		if ok != tt.found {
+			t.Errorf("%q: expected found %v, got %v", tt.path, tt.found, ok)
+			continue
+		}
+		if ok && node.Kind() != tt.kind {
+			t.Errorf("%q: expected %s, got %s", tt.path, tt.kind, node.Kind())
+		}
+	}
+}
+
+func TestPreorder_EmptyKeys(t *testing.T) {
+	root, err := Parse(`{"": 1, a: {"": [1, 2]}}`)
+	if err != nil {
+		t.Fatalf("returned unexpected error %v", err)
+	}
+
+	paths := make([]string, 0)
+	for p, node := range Preorder(root) {
+		paths = append(paths, p.String())
+		if !p.Equals(node.Path()) {
+			t.Errorf("expected yielded path %q to match node path %q", p, node.Path())
+		}
+		found, ok := Lookup(root, p)
+		if !ok || found != node {
+			t.Errorf("%q: expected lookup to find the yielded node", p)
+		}
+	}
+
+	want := []string{"", `[""]`, "a", `a[""]`, `a[""][0]`, `a[""][1]`}
+	if !slices.Equal(paths, want) {
+		t.Errorf("expected %q, got %q", want, paths)
+	}
+
+	if _, ok := Lookup(root, path.Must(0)); ok {
+		t.Errorf("expected index 0 not to resolve in an object")
+	}
+}
@@ -0,0 +1,647 @@
+package json5
+
+import (
+	"cmp"
+	"encoding"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/Roundaround/json5-go/ast"
+	"github.com/Roundaround/json5-go/internal/fields"
+	"github.com/Roundaround/json5-go/path"
+)
+
+type SlicePolicy int
+
+const (
+	// ReplaceSlices replaces an existing slice with the decoded elements.
+	ReplaceSlices SlicePolicy = iota
+	// AppendSlices appends the decoded elements to an existing slice.
+	AppendSlices
+	// MergeSlices decodes each element into the existing element at the same
+	// index, keeping any existing elements beyond the end of the array.
+	MergeSlices
+)
+
+type MapPolicy int
+
+const (
+	// MergeMaps decodes each member into the existing entry with the same key,
+	// keeping entries whose keys are absent from the object.
+	MergeMaps MapPolicy = iota
+	// ReplaceMaps replaces an existing map with the decoded entries.
+	ReplaceMaps
+)
+
+type DecodeOption func(*decoder)
+
+// Slices sets how arrays are decoded into slices that already hold elements.
+// The default is ReplaceSlices. Fixed-size arrays are merged with MergeSlices
+// and replaced otherwise.
+func Slices(policy SlicePolicy) DecodeOption {
+	return func(d *decoder) {
+		d.slices = policy
+	}
+}
+
+// Maps sets how objects are decoded into maps that already hold entries. The
+// default is MergeMaps.
+func Maps(policy MapPolicy) DecodeOption {
+	return func(d *decoder) {
+		d.maps = policy
+	}
+}
+
+// DisallowUnknownFields reports object members that do not match any field of
+// the struct they are decoded into, instead of ignoring them.
+func DisallowUnknownFields() DecodeOption {
+	return func(d *decoder) {
+		d.disallowUnknown = true
+	}
+}
+
+// DisallowDuplicateKeys reports every repeated key in the document, instead
+// of keeping the last occurrence.
+func DisallowDuplicateKeys() DecodeOption {
+	return func(d *decoder) {
+		d.disallowDuplicates = true
+	}
+}
+
+// AllErrors continues decoding past values that cannot be decoded, such as
+// type mismatches and numbers that overflow their fields, so that every
+// problem in the document is reported at once. Values that fail to decode are
+// skipped.
+func AllErrors() DecodeOption {
+	return func(d *decoder) {
+		d.allErrors = true
+	}
+}
+
+// Unmarshal parses data and stores the result in the value pointed to by v.
+//
+// Values are decoded as encoding/json would: objects decode into structs and
+// maps, arrays into slices and arrays, and into an empty interface as the
+// values produced by ast.ToInterface. NaN and Infinity decode into floats.
+// A destination of type ast.Node receives a copy of the value's subtree.
+//
+// Decoding merges into the existing value: struct fields absent from the
+// object keep their values, and existing maps and slices are combined with
+// the decoded ones as set by Maps and Slices. An absent field that still holds
+// its zero value is set from its default tag, a JSON5 literal:
+//
+//	Host string `json:"host" default:"'localhost'"`
+//
+// Since a zero value cannot be told apart from one that was never set, a field
+// the caller set to its zero value, such as false, is also overwritten by its
+// default. Use a pointer field to keep an explicit zero value. Absent struct
+// fields without a default tag have the defaults of their own fields applied.
+//
+// A struct field with the required tag option must be present in the object:
+//
+//	Port int `json:"port,required"`
+//
+// Missing fields, along with unknown fields and duplicate keys when those are
+// disallowed, are all reported rather than only the first; with AllErrors, so
+// is every other problem. Errors are returned as a *DecodeError, or a
+// *DecodeErrors if there is more than one. If data is not valid JSON5, nothing
+// is decoded and the syntax error is returned as an *ast.ParseError.
+func Unmarshal(data []byte, v any, opts ...DecodeOption) error {
+	d := newDecoder(opts)
+	var popts []ast.ParseOption
+	if d.disallowDuplicates {
+		popts = append(popts, ast.DuplicateKeys(ast.CollectDuplicates))
+	}
+	node, err := ast.Parse(string(data), popts...)
+	if err != nil {
+		return err
+	}
+	return d.unmarshal(node, v)
+}
+
+// UnmarshalNode stores the value of an already parsed tree in the value
+// pointed to by v, as Unmarshal. Duplicate keys can only be reported if the
+// tree was parsed with a DuplicateKeyPolicy that records them.
+func UnmarshalNode(node ast.Node, v any, opts ...DecodeOption) error {
+	return newDecoder(opts).unmarshal(node, v)
+}
+
+var nodeType = reflect.TypeFor[ast.Node]()
+
+type decoder struct {
+	disallowUnknown    bool
+	disallowDuplicates bool
+	allErrors          bool
+	slices             SlicePolicy
+	maps               MapPolicy
+
+	errs []*DecodeError
+}
+
+func newDecoder(opts []DecodeOption) *decoder {
+	d := &decoder{}
+	for _, opt := range opts {
+		opt(d)
+	}
+	return d
+}
+
+func (d *decoder) unmarshal(node ast.Node, v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return fmt.Errorf("json5: cannot unmarshal into non-pointer %s", reflect.TypeOf(v))
+	}
+
+	if d.disallowDuplicates {
+		d.checkDuplicates(node)
+	}
+	if err := d.decode(node, rv.Elem()); err != nil {
+		d.collect(err)
+	}
+
+	switch len(d.errs) {
+	case 0:
+		return nil
+	case 1:
+		return d.errs[0]
+	default:
+		slices.SortStableFunc(d.errs, func(a, b *DecodeError) int {
+			return cmp.Compare(a.Offset(), b.Offset())
+		})
+		return &DecodeErrors{d.errs}
+	}
+}
+
+// collect records a decoding error to be reported once decoding is complete.
+func (d *decoder) collect(err error) {
+	switch err := err.(type) {
+	case *DecodeError:
+		d.errs = append(d.errs, err)
+	case *DecodeErrors:
+		d.errs = append(d.errs, err.errs...)
+	}
+}
+
+// checkDuplicates reports every occurrence of a key after its first within
+// the same object.
+func (d *decoder) checkDuplicates(root ast.Node) {
+	for p, node := range ast.Preorder(root) {
+		obj, ok := node.(*ast.ObjectNode)
+		if !ok || len(obj.Duplicates()) == 0 {
+			continue
+		}
+
+		members := slices.Concat(obj.Members(), obj.Duplicates())
+		slices.SortFunc(members, func(a, b *ast.Member) int {
+			return cmp.Compare(a.Offset(), b.Offset())
+		})
+		members = slices.Compact(members)
+
+		first := make(map[string]*ast.Member)
+		for _, m := range members {
+			f, ok := first[m.Key()]
+			if !ok {
+				first[m.Key()] = m
+				continue
+			}
+			err := fmt.Errorf("%w (first defined at ln %d, col %d)", ErrDuplicateKey, f.Line(), f.Column())
+			d.collect(d.keyError(p, m, err))
+		}
+	}
+}
+
+func (d *decoder) decode(node ast.Node, v reflect.Value) error {
+	if v.Type() == nodeType {
+		v.Set(reflect.ValueOf(ast.Clone(node)))
+		return nil
+	}
+
+	if v.Kind() == reflect.Interface && !v.IsNil() {
+		// Decode into the value an interface already points to, as
+		// encoding/json does
+		if e := v.Elem(); e.Kind() == reflect.Pointer && !e.IsNil() && node.Kind() != ast.NULL {
+			return d.decode(node, e.Elem())
+		}
+	}
+
+	null := node.Kind() == ast.NULL
+	if null && (v.Kind() == reflect.Interface || v.Kind() == reflect.Pointer) {
+		v.SetZero()
+		return nil
+	}
+
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return d.decode(node, v.Elem())
+	}
+
+	// As in encoding/json, null is passed to Unmarshaler and json.Unmarshaler
+	// implementations but leaves other values, including TextUnmarshalers,
+	// unchanged
+	if v.CanAddr() {
+		switch u := v.Addr().Interface().(type) {
+		case Unmarshaler:
+			return d.wrap(node, u.UnmarshalJSON5(node))
+		case json.Unmarshaler:
+			data, err := toJSON(node)
+			if err != nil {
+				return d.wrap(node, err)
+			}
+			return d.wrap(node, u.UnmarshalJSON(data))
+		case encoding.TextUnmarshaler:
+			if null {
+				return nil
+			}
+			s, ok := node.(*ast.StringNode)
+			if !ok {
+				return d.mismatch(node, ast.STRING)
+			}
+			return d.wrap(node, u.UnmarshalText([]byte(s.Value())))
+		}
+	}
+
+	if null {
+		switch v.Kind() {
+		case reflect.Map, reflect.Slice:
+			v.SetZero()
+		}
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		n, ok := node.(*ast.BooleanNode)
+		if !ok {
+			return d.mismatch(node, ast.BOOLEAN)
+		}
+		v.SetBool(n.Value())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, ok := node.(*ast.NumberNode)
+		if !ok {
+			return d.mismatch(node, ast.NUMBER)
+		}
+		if !n.IsInteger() {
+			return d.errorf(node, "expected integer, got %s", n.Raw())
+		}
+		i, err := n.Int64()
+		if err != nil || v.OverflowInt(i) {
+			return d.errorf(node, "number %s does not fit in %s", n.Raw(), v.Type())
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		n, ok := node.(*ast.NumberNode)
+		if !ok {
+			return d.mismatch(node, ast.NUMBER)
+		}
+		if !n.IsInteger() {
+			return d.errorf(node, "expected integer, got %s", n.Raw())
+		}
+		u, err := n.Uint64()
+		if err != nil || v.OverflowUint(u) {
+			return d.errorf(node, "number %s does not fit in %s", n.Raw(), v.Type())
+		}
+		v.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		var f float64
+		switch n := node.(type) {
+		case *ast.NumberNode:
+			var err error
+			f, err = n.Float64()
+			if err != nil || v.OverflowFloat(f) {
+				return d.errorf(node, "number %s does not fit in %s", n.Raw(), v.Type())
+			}
+		case *ast.InfinityNode:
+			f = n.Value()
+		case *ast.NaNNode:
+			f = n.Value()
+		default:
+			return d.mismatch(node, ast.NUMBER)
+		}
+		v.SetFloat(f)
+	case reflect.String:
+		n, ok := node.(*ast.StringNode)
+		if !ok {
+			return d.mismatch(node, ast.STRING)
+		}
+		v.SetString(n.Value())
+	case reflect.Interface:
+		if v.NumMethod() > 0 {
+			return d.errorf(node, "cannot decode into non-empty interface %s", v.Type())
+		}
+		x, err := ast.ToInterface(node)
+		if err != nil {
+			return d.wrap(node, err)
+		}
+		v.Set(reflect.ValueOf(x))
+	case reflect.Struct:
+		obj, ok := node.(*ast.ObjectNode)
+		if !ok {
+			return d.mismatch(node, ast.OBJECT)
+		}
+		return d.decodeStruct(obj, v)
+	case reflect.Map:
+		obj, ok := node.(*ast.ObjectNode)
+		if !ok {
+			return d.mismatch(node, ast.OBJECT)
+		}
+		return d.decodeMap(obj, v)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			if s, ok := node.(*ast.StringNode); ok {
+				b, err := base64.StdEncoding.DecodeString(s.Value())
+				if err != nil {
+					return d.wrap(node, err)
+				}
+				v.SetBytes(b)
+				return nil
+			}
+		}
+		arr, ok := node.(*ast.ArrayNode)
+		if !ok {
+			return d.mismatch(node, ast.ARRAY)
+		}
+		return d.decodeSlice(arr, v)
+	case reflect.Array:
+		arr, ok := node.(*ast.ArrayNode)
+		if !ok {
+			return d.mismatch(node, ast.ARRAY)
+		}
+		return d.decodeArray(arr, v)
+	default:
+		return d.errorf(node, "unsupported type %s", v.Type())
+	}
+	return nil
+}
+
+func (d *decoder) decodeStruct(obj *ast.ObjectNode, v reflect.Value) error {
+	fs := fields.Of(v.Type())
+	seen := make(map[string]bool)
+	for _, m := range obj.Members() {
+		f, ok := field(fs, m.Key())
+		if !ok {
+			if d.disallowUnknown {
+				d.collect(d.keyError(obj.Path(), m, ErrUnknownField))
+			}
+			continue
+		}
+		seen[f.Name] = true
+
+		fv, err := d.fieldValue(m.Value(), f, v)
+		if err == nil {
+			err = d.decode(m.Value(), fv)
+		}
+		if err := d.fail(err); err != nil {
+			return err
+		}
+	}
+
+	for _, f := range fs {
+		if f.Required && !seen[f.Name] {
+			p := obj.Path()
+			p.Key(f.Name)
+			d.collect(&DecodeError{path: p, node: obj, span: obj.Span(), err: ErrMissingField})
+		}
+	}
+	return d.defaults(obj, obj.Path(), v, seen)
+}
+
+// defaults sets the fields of struct v that are absent from obj, the object
+// at p, from their default tags. Errors are reported at obj, since the tags
+// are not part of the document.
+func (d *decoder) defaults(obj *ast.ObjectNode, p *path.Path, v reflect.Value, seen map[string]bool) error {
+	for _, f := range fields.Of(v.Type()) {
+		if seen[f.Name] {
+			continue
+		}
+		fv, ok := f.Value(v)
+		if !ok {
+			continue
+		}
+
+		p.Key(f.Name)
+		var err error
+		if literal, ok := f.Tag.Lookup("default"); ok {
+			if fv.IsZero() {
+				err = d.decodeDefault(obj, p, literal, fv)
+			}
+		} else if fv.Kind() == reflect.Struct {
+			err = d.defaults(obj, p, fv, nil)
+		}
+		p.Pop()
+
+		if err := d.fail(err); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *decoder) decodeDefault(obj *ast.ObjectNode, p *path.Path, literal string, v reflect.Value) error {
+	node, err := ast.Parse(literal)
+	if err == nil {
+		sub := &decoder{disallowUnknown: d.disallowUnknown, allErrors: d.allErrors, slices: d.slices, maps: d.maps}
+		err = sub.decode(node, v)
+		for _, serr := range sub.errs {
+			d.collect(defaultError(obj, p, literal, serr))
+		}
+	}
+	if err == nil {
+		return nil
+	}
+	return defaultError(obj, p, literal, err)
+}
+
+// defaultError reports err, from decoding the default tag of the field at p,
+// at obj.
+func defaultError(obj *ast.ObjectNode, p *path.Path, literal string, err error) *DecodeError {
+	var derr *DecodeError
+	if errors.As(err, &derr) {
+		err = derr.err
+		if !derr.path.IsEmpty() {
+			err = fmt.Errorf("%s: %w", derr.path, err)
+		}
+	}
+	return &DecodeError{
+		path: p.Clone(),
+		node: obj,
+		span: obj.Span(),
+		err:  fmt.Errorf("invalid default %q: %w", literal, err),
+	}
+}
+
+// field finds the field for key, preferring an exact match to a
+// case-insensitive one.
+func field(fs []fields.Field, key string) (fields.Field, bool) {
+	for _, f := range fs {
+		if f.Name == key {
+			return f, true
+		}
+	}
+	for _, f := range fs {
+		if strings.EqualFold(f.Name, key) {
+			return f, true
+		}
+	}
+	return fields.Field{}, false
+}
+
+// fieldValue returns the field within struct value v, allocating any nil
+// embedded pointers along the way.
+func (d *decoder) fieldValue(node ast.Node, f fields.Field, v reflect.Value) (reflect.Value, error) {
+	for i, x := range f.Index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				if !v.CanSet() {
+					return reflect.Value{}, d.errorf(node, "cannot set embedded pointer to unexported struct %s", v.Type().Elem())
+				}
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, nil
+}
+
+func (d *decoder) decodeMap(obj *ast.ObjectNode, v reflect.Value) error {
+	t := v.Type()
+	if v.IsNil() || d.maps == ReplaceMaps {
+		v.Set(reflect.MakeMapWithSize(t, obj.Len()))
+	}
+
+	for _, m := range obj.Members() {
+		key, err := d.mapKey(obj, m, t.Key())
+		elem := reflect.New(t.Elem()).Elem()
+		if err == nil {
+			if existing := v.MapIndex(key); existing.IsValid() {
+				elem.Set(existing)
+			}
+			err = d.decode(m.Value(), elem)
+		}
+		if err != nil {
+			if err := d.fail(err); err != nil {
+				return err
+			}
+			continue
+		}
+		v.SetMapIndex(key, elem)
+	}
+	return nil
+}
+
+func (d *decoder) mapKey(obj *ast.ObjectNode, m *ast.Member, t reflect.Type) (reflect.Value, error) {
+	key := reflect.New(t)
+	if u, ok := key.Interface().(encoding.TextUnmarshaler); ok && t.Kind() != reflect.String {
+		if err := u.UnmarshalText([]byte(m.Key())); err != nil {
+			return reflect.Value{}, d.keyError(obj.Path(), m, fmt.Errorf("key %q: %w", m.Key(), err))
+		}
+		return key.Elem(), nil
+	}
+
+	key = key.Elem()
+	switch t.Kind() {
+	case reflect.String:
+		key.SetString(m.Key())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(m.Key(), 10, 64)
+		if err != nil || key.OverflowInt(i) {
+			return reflect.Value{}, d.keyError(obj.Path(), m, fmt.Errorf("key %q is not a valid %s", m.Key(), t))
+		}
+		key.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		u, err := strconv.ParseUint(m.Key(), 10, 64)
+		if err != nil || key.OverflowUint(u) {
+			return reflect.Value{}, d.keyError(obj.Path(), m, fmt.Errorf("key %q is not a valid %s", m.Key(), t))
+		}
+		key.SetUint(u)
+	default:
+		return reflect.Value{}, d.keyError(obj.Path(), m, fmt.Errorf("unsupported map key type %s", t))
+	}
+	return key, nil
+}
+
+func (d *decoder) decodeSlice(arr *ast.ArrayNode, v reflect.Value) error {
+	offset, n := 0, arr.Len()
+	switch d.slices {
+	case AppendSlices:
+		offset = v.Len()
+		n += offset
+	case MergeSlices:
+		n = max(n, v.Len())
+	}
+
+	// Decode into a new slice rather than the existing backing array, which
+	// may be shared with other values
+	s := reflect.MakeSlice(v.Type(), n, n)
+	if d.slices != ReplaceSlices {
+		reflect.Copy(s, v)
+	}
+	v.Set(s)
+
+	for i, value := range arr.Values() {
+		if err := d.fail(d.decode(value, s.Index(offset+i))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *decoder) decodeArray(arr *ast.ArrayNode, v reflect.Value) error {
+	for i := range v.Len() {
+		if d.slices != MergeSlices {
+			v.Index(i).SetZero()
+		}
+		value, ok := arr.Value(i)
+		if !ok {
+			continue
+		}
+		if err := d.fail(d.decode(value, v.Index(i))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fail returns err, unless all errors are being collected, in which case it
+// records err and returns nil so that decoding continues.
+func (d *decoder) fail(err error) error {
+	if err == nil || !d.allErrors {
+		return err
+	}
+	d.collect(err)
+	return nil
+}
+
+func (d *decoder) mismatch(node ast.Node, want ast.Kind) error {
+	return d.errorf(node, "expected %s, got %s", want, node.Kind())
+}
+
+func (d *decoder) errorf(node ast.Node, format string, args ...any) error {
+	return d.wrap(node, fmt.Errorf(format, args...))
+}
+
+// wrap attaches the position of node to err, unless err already carries one.
+func (d *decoder) wrap(node ast.Node, err error) error {
+	switch err.(type) {
+	case nil:
+		return nil
+	case *DecodeError, *DecodeErrors:
+		return err
+	}
+	return &DecodeError{path: node.Path(), node: node, span: node.Span(), err: err}
+}
+
+// keyError reports err at the key of member m of the object at p.
+func (d *decoder) keyError(p *path.Path, m *ast.Member, err error) *DecodeError {
+	p = p.Clone()
+	p.Key(m.Key())
+	return &DecodeError{path: p, node: m.Value(), span: m.Span(), err: err}
+}
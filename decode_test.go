@@ -0,0 +1,415 @@
+package json5
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Roundaround/json5-go/ast"
+	"github.com/Roundaround/json5-go/path"
+)
+
+func TestUnmarshal(t *testing.T) {
+	type Inner struct {
+		Ratio float32 `json:"ratio"`
+	}
+	type target struct {
+		*Inner
+		Name   string            `json5:"name"`
+		Count  uint8             `json:"count"`
+		Hex    int               `json:"hex"`
+		Tags   []string          `json:"tags"`
+		Pair   [2]int            `json:"pair"`
+		Ports  map[int]bool      `json:"ports"`
+		Data   []byte            `json:"data"`
+		Any    any               `json:"any"`
+		Raw    ast.Node          `json:"raw"`
+		Limit  *float64          `json:"limit"`
+		Nested map[string]*Inner `json:"nested"`
+		Null   *int              `json:"null"`
+	}
+
+	source := `{
+  // comments and unquoted keys are fine
+  name: 'svc',
+  COUNT: 255,
+  hex: -0x10,
+  tags: ['a', "b",],
+  pair: [1],
+  ports: {'80': true},
+  data: 'aGk=',
+  any: {a: [1, 'x', null]},
+  raw: {keep: 'me'},
+  limit: -Infinity,
+  ratio: .5,
+  nested: {x: {ratio: 1e2}},
+  null: null,
+  unknown: 1,
+}`
+	null := 1
+	got := target{Pair: [2]int{7, 7}, Null: &null}
+	if err := Unmarshal([]byte(source), &got); err != nil {
+		t.Fatalf("returned unexpected error %v", err)
+	}
+
+	if got.Name != "svc" || got.Count != 255 || got.Hex != -16 {
+		t.Errorf("unexpected scalars %q %d %d", got.Name, got.Count, got.Hex)
+	}
+	if !reflect.DeepEqual(got.Tags, []string{"a", "b"}) || got.Pair != [2]int{1, 0} {
+		t.Errorf("unexpected arrays %v %v", got.Tags, got.Pair)
+	}
+	if !reflect.DeepEqual(got.Ports, map[int]bool{80: true}) || string(got.Data) != "hi" {
+		t.Errorf("unexpected map or bytes %v %q", got.Ports, got.Data)
+	}
+	if want := map[string]any{"a": []any{1.0, "x", nil}}; !reflect.DeepEqual(got.Any, want) {
+		t.Errorf("expected %v, got %v", want, got.Any)
+	}
+	if got.Raw == nil || got.Raw.Parent() != nil || got.Raw.Kind() != ast.OBJECT {
+		t.Errorf("expected a detached copy of the raw object, got %v", got.Raw)
+	}
+	if got.Limit == nil || !math.IsInf(*got.Limit, -1) {
+		t.Errorf("expected -Infinity limit, got %v", got.Limit)
+	}
+	if got.Inner == nil || got.Ratio != 0.5 || got.Nested["x"].Ratio != 100 {
+		t.Errorf("unexpected ratios %v %v", got.Inner, got.Nested)
+	}
+	if got.Null != nil {
+		t.Errorf("expected null to clear the pointer")
+	}
+}
+
+func TestUnmarshal_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		target any
+		want   string
+		line   int
+		column int
+	}{
+		{"mismatch", "{a: {b: 'x'}}", &struct{ A struct{ B int } }{},
+			"json5: a.b at ln 1, col 9: expected Number, got String", 1, 9},
+		{"fraction", "[1.5]", &[]int{},
+			"json5: [0] at ln 1, col 2: expected integer, got 1.5", 1, 2},
+		{"overflow", "{\n  n: 256,\n}", &map[string]uint8{},
+			"json5: n at ln 2, col 6: number 256 does not fit in uint8", 2, 6},
+		{"root", "true", new(string),
+			"json5: $ at ln 1, col 1: expected String, got Boolean", 1, 1},
+		{"map key", "{x: 1}", &map[int]int{},
+			`json5: x at ln 1, col 2: key "x" is not a valid int`, 1, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Unmarshal([]byte(tt.source), tt.target)
+			var derr *DecodeError
+			if !errors.As(err, &derr) {
+				t.Fatalf("expected DecodeError, got %v", err)
+			}
+			if err.Error() != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, err.Error())
+			}
+			if derr.Line() != tt.line || derr.Column() != tt.column {
+				t.Errorf("expected ln %d, col %d, got ln %d, col %d", tt.line, tt.column, derr.Line(), derr.Column())
+			}
+		})
+	}
+
+	if err := Unmarshal([]byte("1"), struct{}{}); err == nil {
+		t.Errorf("expected error for non-pointer")
+	}
+	var perr *ast.ParseError
+	if err := Unmarshal([]byte("{"), new(any)); !errors.As(err, &perr) {
+		t.Errorf("expected parse error, got %v", err)
+	}
+}
+
+func TestDecodeError_Annotate(t *testing.T) {
+	source := "{\n  port: 'eighty',\n}"
+	err := Unmarshal([]byte(source), &struct{ Port int }{})
+
+	want := "json5: port at ln 2, col 9:\n" +
+		"  port: 'eighty',\n" +
+		"        ^ expected Number, got String"
+	if got := err.(*DecodeError).Annotate(source); got != want {
+		t.Errorf("expected\n%s\ngot\n%s", want, got)
+	}
+}
+
+func TestUnmarshal_Strict(t *testing.T) {
+	type listener struct {
+		Host string `json:"host"`
+		Port int    `json:"port,required"`
+	}
+	type config struct {
+		Name      string     `json:"name,required"`
+		Listeners []listener `json:"listeners"`
+		Labels    map[string]string
+	}
+
+	source := `{
+  nmae: 'typo',
+  listeners: [
+    {host: 'a', port: 80, host: 'b'},
+    {prot: 81},
+  ],
+  labels: {x: '1', x: '2'},
+}`
+
+	var c config
+	err := Unmarshal([]byte(source), &c, DisallowUnknownFields(), DisallowDuplicateKeys())
+	var errs *DecodeErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("expected DecodeErrors, got %v", err)
+	}
+
+	want := []string{
+		"json5: name at ln 1, col 1: missing required field",
+		"json5: nmae at ln 2, col 3: unknown field",
+		"json5: listeners[0].host at ln 4, col 27: duplicate key (first defined at ln 4, col 6)",
+		"json5: listeners[1].port at ln 5, col 5: missing required field",
+		"json5: listeners[1].prot at ln 5, col 6: unknown field",
+		"json5: labels.x at ln 7, col 20: duplicate key (first defined at ln 7, col 12)",
+	}
+	got := make([]string, 0)
+	for _, e := range errs.Errors() {
+		got = append(got, e.Error())
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected\n%s\ngot\n%s", strings.Join(want, "\n"), strings.Join(got, "\n"))
+	}
+
+	if !errors.Is(err, ErrUnknownField) || !errors.Is(err, ErrMissingField) || !errors.Is(err, ErrDuplicateKey) {
+		t.Errorf("expected error to match every sentinel")
+	}
+	if p := errs.Errors()[2].Path(); !p.Equals(path.Must("listeners", 0, "host")) {
+		t.Errorf("expected path listeners[0].host, got %s", p)
+	}
+
+	// Decoding continues past strict errors, and without the options only the
+	// missing fields are reported
+	if c.Listeners[0].Host != "b" || c.Labels["x"] != "2" {
+		t.Errorf("expected the last duplicate to win, got %+v", c)
+	}
+	err = Unmarshal([]byte(source), &c)
+	if !errors.As(err, &errs) || len(errs.Errors()) != 2 {
+		t.Errorf("expected two missing fields, got %v", err)
+	}
+}
+
+func TestUnmarshal_AllErrors(t *testing.T) {
+	type config struct {
+		Name    string         `json:"name"`
+		Port    uint16         `json:"port"`
+		Ratio   float32        `json:"ratio"`
+		Retries []int          `json:"retries"`
+		Weights map[int]string `json:"weights"`
+		Debug   bool           `json:"debug"`
+	}
+
+	source := `{
+  name: 42,
+  port: 70000,
+  ratio: 1e39,
+  retries: [1, 'two', 3.5],
+  weights: {'1': 'a', b: 'c'},
+  verbose: true,
+  debug: true,
+}`
+
+	var c config
+	err := Unmarshal([]byte(source), &c)
+	if _, ok := err.(*DecodeError); !ok {
+		t.Errorf("expected only the first error without AllErrors, got %v", err)
+	}
+
+	c = config{}
+	err = Unmarshal([]byte(source), &c, AllErrors(), DisallowUnknownFields())
+	var errs *DecodeErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("expected DecodeErrors, got %v", err)
+	}
+
+	want := []struct {
+		path string
+		line int
+		col  int
+	}{
+		{"name", 2, 9},
+		{"port", 3, 9},
+		{"ratio", 4, 10},
+		{"retries[1]", 5, 16},
+		{"retries[2]", 5, 23},
+		{"weights.b", 6, 23},
+		{"verbose", 7, 3},
+	}
+	if len(errs.Errors()) != len(want) {
+		t.Fatalf("expected %d errors, got %d:\n%v", len(want), len(errs.Errors()), err)
+	}
+	for i, e := range errs.Errors() {
+		if e.Path().String() != want[i].path || e.Line() != want[i].line || e.Column() != want[i].col {
+			t.Errorf("%d: expected %s at ln %d, col %d, got %s at ln %d, col %d",
+				i, want[i].path, want[i].line, want[i].col, e.Path(), e.Line(), e.Column())
+		}
+		if e.Span().End.Offset <= e.Span().Start.Offset {
+			t.Errorf("%d: expected a non-empty span, got %s", i, e.Span())
+		}
+	}
+	if unwrapped := errs.Unwrap(); len(unwrapped) != len(want) {
+		t.Errorf("expected Unwrap to return every error, got %d", len(unwrapped))
+	}
+
+	// Values that decoded successfully are kept
+	if !c.Debug || !reflect.DeepEqual(c.Retries, []int{1, 0, 0}) || c.Weights[1] != "a" || len(c.Weights) != 1 {
+		t.Errorf("unexpected partial result %+v", c)
+	}
+
+	annotated := errs.Annotate(source)
+	wantAnnotated := "json5: name at ln 2, col 9:\n" +
+		"  name: 42,\n" +
+		"        ^ expected String, got Number\n\n" +
+		"json5: port at ln 3, col 9:\n" +
+		"  port: 70000,\n" +
+		"        ^ number 70000 does not fit in uint16"
+	if !strings.HasPrefix(annotated, wantAnnotated) {
+		t.Errorf("expected annotation to begin with\n%s\ngot\n%s", wantAnnotated, annotated)
+	}
+}
+
+func TestUnmarshal_Existing(t *testing.T) {
+	type listener struct {
+		Host string `json:"host" default:"'localhost'"`
+		Port int    `json:"port" default:"8080"`
+	}
+	type config struct {
+		Name      string              `json:"name"`
+		Listener  listener            `json:"listener"`
+		Backup    listener            `json:"backup"`
+		Spare     *listener           `json:"spare"`
+		Tags      []string            `json:"tags" default:"['default']"`
+		Pair      [2]int              `json:"pair"`
+		Routes    map[string]listener `json:"routes"`
+		Verbosity int                 `json:"verbosity" default:"1"`
+	}
+
+	source := `{
+  listener: {port: 9000},
+  tags: ['b'],
+  pair: [5],
+  routes: {api: {host: 'api.local'}, web: {port: 81}},
+}`
+	initial := func() config {
+		return config{
+			Name:   "prefilled",
+			Tags:   []string{"a"},
+			Pair:   [2]int{1, 2},
+			Routes: map[string]listener{"api": {Port: 1}, "old": {Port: 2}},
+		}
+	}
+
+	tests := []struct {
+		name   string
+		opts   []DecodeOption
+		tags   []string
+		pair   [2]int
+		routes map[string]listener
+	}{
+		{"defaults", nil, []string{"b"}, [2]int{5, 0}, map[string]listener{
+			"api": {Host: "api.local", Port: 1},
+			"web": {Host: "localhost", Port: 81},
+			"old": {Port: 2},
+		}},
+		{"append and replace", []DecodeOption{Slices(AppendSlices), Maps(ReplaceMaps)}, []string{"a", "b"}, [2]int{5, 0}, map[string]listener{
+			"api": {Host: "api.local", Port: 8080},
+			"web": {Host: "localhost", Port: 81},
+		}},
+		{"merge", []DecodeOption{Slices(MergeSlices)}, []string{"b"}, [2]int{5, 2}, map[string]listener{
+			"api": {Host: "api.local", Port: 1},
+			"web": {Host: "localhost", Port: 81},
+			"old": {Port: 2},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := initial()
+			tags := c.Tags
+			if err := Unmarshal([]byte(source), &c, tt.opts...); err != nil {
+				t.Fatalf("returned unexpected error %v", err)
+			}
+
+			if c.Name != "prefilled" || c.Verbosity != 1 {
+				t.Errorf("expected absent fields to keep values or take defaults, got %q %d", c.Name, c.Verbosity)
+			}
+			if c.Listener != (listener{"localhost", 9000}) || c.Backup != (listener{"localhost", 8080}) || c.Spare != nil {
+				t.Errorf("expected nested defaults, got %+v %+v %+v", c.Listener, c.Backup, c.Spare)
+			}
+			if !reflect.DeepEqual(c.Tags, tt.tags) || tags[0] != "a" {
+				t.Errorf("expected tags %q without modifying the original, got %q", tt.tags, c.Tags)
+			}
+			if c.Pair != tt.pair {
+				t.Errorf("expected pair %v, got %v", tt.pair, c.Pair)
+			}
+			if !reflect.DeepEqual(c.Routes, tt.routes) {
+				t.Errorf("expected routes %+v, got %+v", tt.routes, c.Routes)
+			}
+		})
+	}
+}
+
+func TestUnmarshal_InvalidDefault(t *testing.T) {
+	var c struct {
+		Inner struct {
+			Port int `json:"port" default:"'eighty'"`
+		} `json:"inner"`
+	}
+	err := Unmarshal([]byte("\n{}"), &c)
+	want := `json5: inner.port at ln 2, col 1: invalid default "'eighty'": expected Number, got String`
+	if err == nil || err.Error() != want {
+		t.Errorf("expected %q, got %v", want, err)
+	}
+}
+
+func TestUnmarshal_DefaultErrors(t *testing.T) {
+	type server struct {
+		Host string `json:"host,required"`
+		Port int    `json:"port"`
+	}
+	var c struct {
+		Server server `json:"server" default:"{port: 'x'}"`
+	}
+
+	err := Unmarshal([]byte("{}"), &c)
+	want := `json5: server at ln 1, col 1: invalid default "{port: 'x'}": port: expected Number, got String`
+	if err == nil || err.Error() != want {
+		t.Errorf("expected %q, got %v", want, err)
+	}
+
+	err = Unmarshal([]byte("{}"), &c, AllErrors())
+	var derrs *DecodeErrors
+	if !errors.As(err, &derrs) || len(derrs.Errors()) != 2 {
+		t.Fatalf("expected 2 errors, got %v", err)
+	}
+	if !errors.Is(err, ErrMissingField) {
+		t.Errorf("expected missing field error from default, got %v", err)
+	}
+}
+
+func TestUnmarshal_DefaultsOverwriteZeroValues(t *testing.T) {
+	v := struct {
+		Enabled  bool  `json:"enabled" default:"true"`
+		Optional *bool `json:"optional" default:"true"`
+	}{Optional: new(bool)}
+
+	if err := Unmarshal([]byte("{}"), &v); err != nil {
+		t.Fatalf("returned unexpected error %v", err)
+	}
+	if !v.Enabled {
+		t.Errorf("expected a zero value to be replaced by its default")
+	}
+	if *v.Optional {
+		t.Errorf("expected a pointer to an explicit zero value to be kept")
+	}
+}
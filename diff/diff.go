@@ -0,0 +1,348 @@
+// Package diff computes semantic differences between two JSON5 documents.
+package diff
+
+import (
+	"fmt"
+
+	"github.com/Roundaround/json5-go/ast"
+	"github.com/Roundaround/json5-go/path"
+	"github.com/Roundaround/json5-go/token"
+)
+
+type Kind int
+
+const (
+	UNKNOWN Kind = iota
+	ADDED
+	REMOVED
+	MODIFIED
+	MOVED
+)
+
+func (k Kind) String() string {
+	switch k {
+	case ADDED:
+		return "added"
+	case REMOVED:
+		return "removed"
+	case MODIFIED:
+		return "modified"
+	case MOVED:
+		return "moved"
+	default:
+		return "unknown"
+	}
+}
+
+// Change is a single difference between two trees. Old and New hold the
+// affected node on each side, and are nil for the side where it is absent.
+//
+// OldPosition and NewPosition are the positions of Old and New in their
+// sources. When a node was added, OldPosition is the position of the object or
+// array it was added to, and likewise NewPosition for a removed node.
+type Change struct {
+	Kind        Kind
+	OldPath     *path.Path
+	NewPath     *path.Path
+	Old         ast.Node
+	New         ast.Node
+	OldPosition token.Position
+	NewPosition token.Position
+}
+
+// Path returns the path of the change in the new tree, or in the old tree for
+// a removal.
+func (c *Change) Path() *path.Path {
+	if c.NewPath != nil {
+		return c.NewPath
+	}
+	return c.OldPath
+}
+
+func (c *Change) String() string {
+	if c.Kind == MOVED {
+		return fmt.Sprintf("%s %s -> %s", c.Kind, display(c.OldPath), display(c.NewPath))
+	}
+	return fmt.Sprintf("%s %s", c.Kind, display(c.Path()))
+}
+
+func display(p *path.Path) string {
+	if p.IsEmpty() {
+		return path.Root
+	}
+	return p.String()
+}
+
+// Compare returns the changes that turn old into new. Values are compared with
+// ast.Equal, so formatting, comments, quote style, key order and number
+// spelling are ignored.
+//
+// Array elements are matched by their longest common subsequence. An element
+// that appears on both sides outside that subsequence is reported as MOVED;
+// otherwise unmatched elements in the same gap are compared pairwise, and any
+// left over are ADDED or REMOVED.
+//
+// Either root may be nil for a document that does not exist, in which case
+// the whole of the other is ADDED or REMOVED.
+func Compare(old, new ast.Node) []Change {
+	d := &differ{}
+	switch {
+	case old == nil && new == nil:
+	case old == nil:
+		d.add(ADDED, nil, new, nil, path.Must())
+	case new == nil:
+		d.add(REMOVED, old, nil, path.Must(), nil)
+	default:
+		d.compare(old, new, path.Must(), path.Must())
+	}
+	return d.changes
+}
+
+type differ struct {
+	changes []Change
+}
+
+func (d *differ) compare(old, new ast.Node, oldPath, newPath *path.Path) {
+	switch o := old.(type) {
+	case *ast.ObjectNode:
+		if n, ok := new.(*ast.ObjectNode); ok {
+			d.compareObjects(o, n, oldPath, newPath)
+			return
+		}
+	case *ast.ArrayNode:
+		if n, ok := new.(*ast.ArrayNode); ok {
+			d.compareArrays(o, n, oldPath, newPath)
+			return
+		}
+	}
+
+	if !ast.Equal(old, new) {
+		d.add(MODIFIED, old, new, oldPath, newPath)
+	}
+}
+
+func (d *differ) compareObjects(old, new *ast.ObjectNode, oldPath, newPath *path.Path) {
+	for _, key := range old.Keys() {
+		o, _ := old.Value(key)
+		oldPath.Key(key)
+		if n, ok := new.Value(key); ok {
+			newPath.Key(key)
+			d.compare(o, n, oldPath, newPath)
+			newPath.Pop()
+		} else {
+			d.add(REMOVED, o, new, oldPath, nil)
+		}
+		oldPath.Pop()
+	}
+
+	for _, key := range new.Keys() {
+		if _, ok := old.Value(key); ok {
+			continue
+		}
+		n, _ := new.Value(key)
+		newPath.Key(key)
+		d.add(ADDED, old, n, nil, newPath)
+		newPath.Pop()
+	}
+}
+
+func (d *differ) compareArrays(old, new *ast.ArrayNode, oldPath, newPath *path.Path) {
+	a, b := old.Values(), new.Values()
+	pairs := lcs(a, b)
+
+	// Number the gaps between matched pairs so that unmatched elements are
+	// only compared with others in the same gap.
+	oldGap := make([]int, len(a))
+	newGap := make([]int, len(b))
+	for i := range oldGap {
+		oldGap[i] = -1
+	}
+	for j := range newGap {
+		newGap[j] = -1
+	}
+	for _, p := range pairs {
+		oldGap[p[0]], newGap[p[1]] = -2, -2
+	}
+	assign(oldGap)
+	assign(newGap)
+
+	// Unmatched elements with an equal counterpart elsewhere were moved
+	var moves [][2]int
+	for i := range a {
+		if oldGap[i] < 0 {
+			continue
+		}
+		for j := range b {
+			if newGap[j] >= 0 && ast.Equal(a[i], b[j]) {
+				moves = append(moves, [2]int{i, j})
+				oldGap[i], newGap[j] = -2, -2
+				break
+			}
+		}
+	}
+
+	for g := range len(pairs) + 1 {
+		olds := indicesIn(oldGap, g)
+		news := indicesIn(newGap, g)
+		for _, p := range pair(a, b, olds, news) {
+			i, j := p[0], p[1]
+			switch {
+			case i >= 0 && j >= 0:
+				oldPath.Index(i)
+				newPath.Index(j)
+				d.compare(a[i], b[j], oldPath, newPath)
+				oldPath.Pop()
+				newPath.Pop()
+			case i >= 0:
+				oldPath.Index(i)
+				d.add(REMOVED, a[i], new, oldPath, nil)
+				oldPath.Pop()
+			default:
+				newPath.Index(j)
+				d.add(ADDED, old, b[j], nil, newPath)
+				newPath.Pop()
+			}
+		}
+	}
+
+	for _, m := range moves {
+		oldPath.Index(m[0])
+		newPath.Index(m[1])
+		d.add(MOVED, a[m[0]], b[m[1]], oldPath, newPath)
+		oldPath.Pop()
+		newPath.Pop()
+	}
+}
+
+// add records a change. For additions and removals, the node on the missing
+// side is the container, which only supplies the position, or nil for a
+// missing document.
+func (d *differ) add(kind Kind, old, new ast.Node, oldPath, newPath *path.Path) {
+	c := Change{Kind: kind, Old: old, New: new}
+	if old != nil {
+		c.OldPosition = old.Start()
+	}
+	if new != nil {
+		c.NewPosition = new.Start()
+	}
+	if oldPath != nil {
+		c.OldPath = oldPath.Clone()
+	}
+	if newPath != nil {
+		c.NewPath = newPath.Clone()
+	}
+	switch kind {
+	case ADDED:
+		c.Old = nil
+	case REMOVED:
+		c.New = nil
+	}
+	d.changes = append(d.changes, c)
+}
+
+// lcs returns the index pairs of the longest common subsequence of a and b.
+func lcs(a, b []ast.Node) [][2]int {
+	hashes := func(nodes []ast.Node) []uint64 {
+		h := make([]uint64, len(nodes))
+		for i, n := range nodes {
+			h[i] = ast.Hash(n)
+		}
+		return h
+	}
+	ha, hb := hashes(a), hashes(b)
+	equal := func(i, j int) bool {
+		return ha[i] == hb[j] && ast.Equal(a[i], b[j])
+	}
+
+	// lengths[i][j] is the length of the LCS of a[i:] and b[j:]
+	lengths := make([][]int, len(a)+1)
+	for i := range lengths {
+		lengths[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if equal(i, j) {
+				lengths[i][j] = lengths[i+1][j+1] + 1
+			} else {
+				lengths[i][j] = max(lengths[i+1][j], lengths[i][j+1])
+			}
+		}
+	}
+
+	var pairs [][2]int
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case equal(i, j):
+			pairs = append(pairs, [2]int{i, j})
+			i++
+			j++
+		case lengths[i+1][j] >= lengths[i][j+1]:
+			i++
+		default:
+			j++
+		}
+	}
+	return pairs
+}
+
+// pair matches the unmatched old and new elements of a gap, preferring
+// elements of the same kind, and then any others in order. Elements left
+// without a partner are paired with -1.
+func pair(a, b []ast.Node, olds, news []int) [][2]int {
+	var pairs [][2]int
+	paired := make([]bool, len(news))
+	var unpaired []int
+	for _, i := range olds {
+		k := -1
+		for n, j := range news {
+			if !paired[n] && a[i].Kind() == b[j].Kind() {
+				k = n
+				break
+			}
+		}
+		if k < 0 {
+			unpaired = append(unpaired, i)
+			continue
+		}
+		paired[k] = true
+		pairs = append(pairs, [2]int{i, news[k]})
+	}
+	for k, j := range news {
+		if paired[k] {
+			continue
+		}
+		if len(unpaired) > 0 {
+			pairs = append(pairs, [2]int{unpaired[0], j})
+			unpaired = unpaired[1:]
+		} else {
+			pairs = append(pairs, [2]int{-1, j})
+		}
+	}
+	for _, i := range unpaired {
+		pairs = append(pairs, [2]int{i, -1})
+	}
+	return pairs
+}
+
+// assign replaces each unmatched index (-1) with the number of its gap, and
+// leaves matched indices (-2) alone.
+func assign(gaps []int) {
+	gap := 0
+	for i, g := range gaps {
+		if g == -2 {
+			gap++
+			continue
+		}
+		gaps[i] = gap
+	}
+}
+
+func indicesIn(gaps []int, gap int) []int {
+	var indices []int
+	for i, g := range gaps {
+		if g == gap {
+			indices = append(indices, i)
+		}
+	}
+	return indices
+}
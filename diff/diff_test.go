@@ -0,0 +1,112 @@
+package diff
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/Roundaround/json5-go/ast"
+)
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		name     string
+		old, new string
+		want     []string
+	}{
+		{"equal", `{a: 0x10, b: 'x'}`, `{b: "x", a: 16}`, []string{}},
+		{"scalar", `1`, `2`, []string{"modified $"}},
+		{"kind", `{a: [1]}`, `{a: {}}`, []string{"modified a"}},
+		{"keys", `{a: 1, b: 2}`, `{b: 3, c: 4}`, []string{"removed a", "modified b", "added c"}},
+		{"nested", `{a: {b: {c: 1}}}`, `{a: {b: {c: 2}}}`, []string{"modified a.b.c"}},
+		{"append", `[1, 2]`, `[1, 2, 3]`, []string{"added [2]"}},
+		{"remove", `[1, 2, 3]`, `[1, 3]`, []string{"removed [1]"}},
+		{"replace", `[1, 2, 3]`, `[1, 5, 3]`, []string{"modified [1]"}},
+		{"move", `['a', 'b', 'c']`, `['b', 'c', 'a']`, []string{"moved [0] -> [2]"}},
+		{"shifted", `[0, {x: 1}]`, `[{x: 2}]`, []string{"modified [0].x", "removed [0]"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changes := Compare(parse(t, tt.old), parse(t, tt.new))
+			got := make([]string, 0, len(changes))
+			for _, c := range changes {
+				got = append(got, c.String())
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestCompare_Positions(t *testing.T) {
+	old := "{\n  a: 1,\n  list: [1, 2],\n}"
+	new := "{\n  list: [1, 2, 3],\n  a: 2,\n}"
+
+	changes := Compare(parse(t, old), parse(t, new))
+	if len(changes) != 2 {
+		t.Fatalf("expected 2 changes, got %d", len(changes))
+	}
+
+	modified := changes[0]
+	if modified.Kind != MODIFIED || modified.OldPath.String() != "a" || modified.NewPath.String() != "a" {
+		t.Errorf("unexpected change %s", modified.String())
+	}
+	if modified.OldPosition.String() != "2:6" || modified.NewPosition.String() != "3:6" {
+		t.Errorf("expected positions 2:6 and 3:6, got %s and %s", modified.OldPosition, modified.NewPosition)
+	}
+
+	added := changes[1]
+	if added.Kind != ADDED || added.Old != nil || added.OldPath != nil || added.Path().String() != "list[2]" {
+		t.Errorf("unexpected change %s", added.String())
+	}
+	if added.OldPosition.String() != "3:9" || added.NewPosition.String() != "2:16" {
+		t.Errorf("expected positions 3:9 and 2:16, got %s and %s", added.OldPosition, added.NewPosition)
+	}
+}
+
+func TestCompare_NilRoots(t *testing.T) {
+	doc := parse(t, `{a: 1}`)
+
+	if changes := Compare(nil, nil); len(changes) != 0 {
+		t.Errorf("expected no changes, got %v", changes)
+	}
+
+	added := Compare(nil, doc)
+	if len(added) != 1 || added[0].Kind != ADDED || added[0].New != doc || added[0].Old != nil || added[0].String() != "added $" {
+		t.Errorf("expected the whole document to be added, got %v", added)
+	}
+
+	removed := Compare(doc, nil)
+	if len(removed) != 1 || removed[0].Kind != REMOVED || removed[0].Old != doc || removed[0].New != nil || removed[0].String() != "removed $" {
+		t.Errorf("expected the whole document to be removed, got %v", removed)
+	}
+}
+
+func TestFormat(t *testing.T) {
+	old := "{\n\tlimits: [1, 2],\n\tname: 'x',\n}"
+	new := "{\n\tlimits: [1, 3],\n}"
+
+	got := Format(Compare(parse(t, old), parse(t, new)), old, new)
+	want := "modified limits[1] (ln 2, col 14 -> ln 2, col 14)\n" +
+		"  \tlimits: [1, 2],\n" +
+		"  \t            ^ was 2\n" +
+		"  \tlimits: [1, 3],\n" +
+		"  \t            ^ now 3\n" +
+		"\n" +
+		"removed name (ln 3, col 8 -> ln 1, col 1)\n" +
+		"  \tname: 'x',\n" +
+		"  \t      ^ removed 'x'\n"
+	if got != want {
+		t.Errorf("expected:\n%s\ngot:\n%s", want, got)
+	}
+}
+
+func parse(t *testing.T, source string) ast.Node {
+	t.Helper()
+	node, err := ast.Parse(source)
+	if err != nil {
+		t.Fatalf("returned unexpected error %v", err)
+	}
+	return node
+}
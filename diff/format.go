@@ -0,0 +1,69 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Roundaround/json5-go/annotate"
+	"github.com/Roundaround/json5-go/ast"
+)
+
+// Format renders changes for people to read. Each change is followed by the
+// affected line of the old and/or new source, with a caret marking the value.
+//
+//	modified limits[1] (ln 3, col 14 -> ln 3, col 14)
+//	  limits: [1, 2],
+//	              ^ was 2
+//	  limits: [1, 3],
+//	              ^ now 3
+func Format(changes []Change, oldSource, newSource string) string {
+	var b strings.Builder
+	for i, c := range changes {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		fmt.Fprintf(&b, "%s (ln %d, col %d -> ln %d, col %d)\n", c.String(),
+			c.OldPosition.Line, c.OldPosition.Column, c.NewPosition.Line, c.NewPosition.Column)
+
+		switch c.Kind {
+		case ADDED:
+			excerpt(&b, newSource, c.New, "added ")
+		case REMOVED:
+			excerpt(&b, oldSource, c.Old, "removed ")
+		case MOVED:
+			excerpt(&b, oldSource, c.Old, "moved from here")
+			excerpt(&b, newSource, c.New, "to here")
+		default:
+			excerpt(&b, oldSource, c.Old, "was ")
+			excerpt(&b, newSource, c.New, "now ")
+		}
+	}
+	return b.String()
+}
+
+// excerpt writes the line of source holding node, indented, with a caret and
+// message beneath it. A message ending in a space is followed by the node's
+// source text.
+func excerpt(b *strings.Builder, source string, node ast.Node, message string) {
+	if strings.HasSuffix(message, " ") {
+		message += snippet(source, node)
+	}
+	for _, line := range strings.Split(annotate.Line(source, node.Offset(), message), "\n") {
+		b.WriteString("  ")
+		b.WriteString(line)
+		b.WriteByte('\n')
+	}
+}
+
+// snippet returns the first line of a node's source text.
+func snippet(source string, node ast.Node) string {
+	span := node.Span()
+	if span.Start.Offset < 0 || span.End.Offset > len(source) || span.Len() <= 0 {
+		return node.Kind().String()
+	}
+	text := source[span.Start.Offset:span.End.Offset]
+	if i := strings.IndexAny(text, "\r\n\u2028\u2029"); i >= 0 {
+		text = strings.TrimRight(text[:i], " \t") + " ..."
+	}
+	return text
+}
@@ -0,0 +1,439 @@
+package json5
+
+import (
+	"encoding"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/Roundaround/json5-go/ast"
+	"github.com/Roundaround/json5-go/internal/fields"
+	"github.com/Roundaround/json5-go/path"
+	"github.com/Roundaround/json5-go/printer"
+)
+
+type CommentStyle int
+
+const (
+	// LineComments writes each line of a comment as a "//" comment.
+	LineComments CommentStyle = iota
+	// BlockComments writes each comment as a single "/* */" comment.
+	BlockComments
+)
+
+func (s CommentStyle) String() string {
+	switch s {
+	case LineComments:
+		return "Line"
+	case BlockComments:
+		return "Block"
+	default:
+		return "Unknown"
+	}
+}
+
+type MarshalOption func(*encoder)
+
+// Indent writes each member and element on its own line, indented by indent
+// per level. Without it, output is compact and comments are omitted.
+func Indent(indent string) MarshalOption {
+	return func(e *encoder) {
+		e.indent = indent
+	}
+}
+
+// UnquotedKeys leaves object keys unquoted where they are valid identifiers.
+func UnquotedKeys() MarshalOption {
+	return func(e *encoder) {
+		e.unquotedKeys = true
+	}
+}
+
+// TrailingCommas follows the last member or element with a comma when
+// indenting.
+func TrailingCommas() MarshalOption {
+	return func(e *encoder) {
+		e.trailingCommas = true
+	}
+}
+
+// Comments writes the text of each struct field's comment tag, or its doc tag
+// if it has none, as a comment above the field's member:
+//
+//	Port int `json:"port" comment:"Port to listen on."`
+//
+// Multi-line text produces one line comment per line, or a single block
+// comment.
+func Comments(style CommentStyle) MarshalOption {
+	return func(e *encoder) {
+		e.comments = true
+		e.style = style
+	}
+}
+
+type encoder struct {
+	indent         string
+	unquotedKeys   bool
+	trailingCommas bool
+	comments       bool
+	style          CommentStyle
+
+	path    *path.Path
+	visited map[visit]bool
+}
+
+// visit identifies a map, slice or pointer being encoded, to detect cycles.
+type visit struct {
+	ptr uintptr
+	typ reflect.Type
+	len int
+}
+
+func newEncoder(opts []MarshalOption) *encoder {
+	e := &encoder{path: path.Must(), visited: make(map[visit]bool)}
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e
+}
+
+// Marshal returns the JSON5 encoding of v.
+//
+// Values are encoded as encoding/json would, except that NaN and infinite
+// floats are written as NaN and Infinity, and ast nodes are written as-is.
+// Map keys are sorted.
+func Marshal(v any, opts ...MarshalOption) ([]byte, error) {
+	e := newEncoder(opts)
+	node, err := e.encode(addressable(v))
+	if err != nil {
+		return nil, err
+	}
+
+	popts := []printer.Option{printer.Indent(e.indent)}
+	if e.unquotedKeys {
+		popts = append(popts, printer.UnquotedKeys())
+	}
+	if e.trailingCommas {
+		popts = append(popts, printer.TrailingCommas())
+	}
+	return []byte(printer.Sprint(node, popts...)), nil
+}
+
+// MarshalNode returns v as an ast tree, with comments attached if requested.
+func MarshalNode(v any, opts ...MarshalOption) (ast.Node, error) {
+	return newEncoder(opts).encode(addressable(v))
+}
+
+// addressable returns v in an addressable copy, so that methods with pointer
+// receivers are found on its fields as they are in encoding/json.
+func addressable(v any) reflect.Value {
+	rv := reflect.ValueOf(v)
+	if !rv.IsValid() || rv.Kind() == reflect.Pointer {
+		return rv
+	}
+	c := reflect.New(rv.Type()).Elem()
+	c.Set(rv)
+	return c
+}
+
+func (e *encoder) encode(v reflect.Value) (ast.Node, error) {
+	if !v.IsValid() || (v.Kind() == reflect.Interface || v.Kind() == reflect.Pointer) && v.IsNil() {
+		return ast.NewNull(), nil
+	}
+
+	switch x := v.Interface().(type) {
+	case ast.Node:
+		return ast.Clone(x), nil
+	case ast.Number, json.Number, *big.Int, *big.Float:
+		return ast.FromInterface(x)
+	}
+
+	if node, ok, err := e.marshal(v); ok {
+		if err != nil {
+			return nil, e.errorf("%s: %v", v.Type(), err)
+		}
+		return node, nil
+	}
+
+	switch v.Kind() {
+	case reflect.Interface:
+		return e.encode(v.Elem())
+	case reflect.Pointer:
+		if err := e.enter(v); err != nil {
+			return nil, err
+		}
+		defer e.leave(v)
+		return e.encode(v.Elem())
+	case reflect.Bool:
+		return ast.NewBool(v.Bool()), nil
+	case reflect.String:
+		return ast.NewString(v.String()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return ast.NewNumber(v.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return ast.NewNumber(v.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		f := v.Float()
+		switch {
+		case math.IsNaN(f):
+			return ast.NewNaN(), nil
+		case math.IsInf(f, 0):
+			return ast.NewInfinity(f < 0), nil
+		case v.Kind() == reflect.Float32:
+			return ast.NewNumber(float32(f)), nil
+		default:
+			return ast.NewNumber(f), nil
+		}
+	case reflect.Struct:
+		return e.encodeStruct(v)
+	case reflect.Map:
+		return e.encodeMap(v)
+	case reflect.Slice:
+		if v.IsNil() {
+			return ast.NewNull(), nil
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return ast.NewString(base64.StdEncoding.EncodeToString(v.Bytes())), nil
+		}
+		if err := e.enter(v); err != nil {
+			return nil, err
+		}
+		defer e.leave(v)
+		return e.encodeArray(v)
+	case reflect.Array:
+		return e.encodeArray(v)
+	default:
+		return nil, e.errorf("unsupported type %s", v.Type())
+	}
+}
+
+// marshal encodes v with its Marshaler, json.Marshaler or
+// encoding.TextMarshaler implementation, if it has one.
+func (e *encoder) marshal(v reflect.Value) (ast.Node, bool, error) {
+	m := v.Interface()
+	if v.Kind() != reflect.Pointer && v.CanAddr() {
+		switch v.Addr().Interface().(type) {
+		case Marshaler, json.Marshaler, encoding.TextMarshaler:
+			m = v.Addr().Interface()
+		}
+	}
+
+	switch m := m.(type) {
+	case Marshaler:
+		node, err := m.MarshalJSON5()
+		switch {
+		case err != nil:
+			return nil, true, err
+		case node == nil:
+			return ast.NewNull(), true, nil
+		case node.Parent() != nil:
+			return ast.Clone(node), true, nil
+		}
+		return node, true, nil
+	case json.Marshaler:
+		data, err := m.MarshalJSON()
+		if err != nil {
+			return nil, true, err
+		}
+		node, err := ast.Parse(string(data))
+		return node, true, err
+	case encoding.TextMarshaler:
+		text, err := m.MarshalText()
+		if err != nil {
+			return nil, true, err
+		}
+		return ast.NewString(string(text)), true, nil
+	}
+	return nil, false, nil
+}
+
+func (e *encoder) encodeStruct(v reflect.Value) (ast.Node, error) {
+	obj := ast.NewObject()
+	for _, f := range fields.Of(v.Type()) {
+		fv, ok := f.Value(v)
+		if !ok || f.OmitEmpty && isEmpty(fv) {
+			continue
+		}
+
+		e.path.Key(f.Name)
+		node, err := e.encode(fv)
+		if err != nil {
+			return nil, err
+		}
+		if e.comments {
+			e.comment(node, f.Tag)
+		}
+		e.path.Pop()
+
+		obj.Set(f.Name, node)
+	}
+	return obj, nil
+}
+
+func (e *encoder) encodeMap(v reflect.Value) (ast.Node, error) {
+	if v.IsNil() {
+		return ast.NewNull(), nil
+	}
+	if err := e.enter(v); err != nil {
+		return nil, err
+	}
+	defer e.leave(v)
+
+	type entry struct {
+		key   string
+		value reflect.Value
+	}
+	entries := make([]entry, 0, v.Len())
+	iter := v.MapRange()
+	for iter.Next() {
+		key, err := e.mapKey(iter.Key())
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry{key, iter.Value()})
+	}
+	slices.SortFunc(entries, func(a, b entry) int {
+		return strings.Compare(a.key, b.key)
+	})
+
+	obj := ast.NewObject()
+	for _, entry := range entries {
+		e.path.Key(entry.key)
+		node, err := e.encode(entry.value)
+		if err != nil {
+			return nil, err
+		}
+		e.path.Pop()
+		obj.Set(entry.key, node)
+	}
+	return obj, nil
+}
+
+func (e *encoder) mapKey(k reflect.Value) (string, error) {
+	switch k.Kind() {
+	case reflect.String:
+		return k.String(), nil
+	}
+	if m, ok := k.Interface().(encoding.TextMarshaler); ok {
+		if k.Kind() == reflect.Pointer && k.IsNil() {
+			return "", nil
+		}
+		text, err := m.MarshalText()
+		if err != nil {
+			return "", e.errorf("map key %v: %v", k, err)
+		}
+		return string(text), nil
+	}
+	switch k.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(k.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(k.Uint(), 10), nil
+	default:
+		return "", e.errorf("unsupported map key type %s", k.Type())
+	}
+}
+
+func (e *encoder) encodeArray(v reflect.Value) (ast.Node, error) {
+	arr := ast.NewArray()
+	for i := range v.Len() {
+		e.path.Index(i)
+		node, err := e.encode(v.Index(i))
+		if err != nil {
+			return nil, err
+		}
+		e.path.Pop()
+		arr.Append(node)
+	}
+	return arr, nil
+}
+
+// comment attaches the text of a field's comment or doc tag to node.
+func (e *encoder) comment(node ast.Node, tag reflect.StructTag) {
+	text, ok := tag.Lookup("comment")
+	if !ok {
+		text, ok = tag.Lookup("doc")
+	}
+	if !ok || text == "" {
+		return
+	}
+
+	lines := strings.Split(text, "\n")
+	if e.style == LineComments {
+		for _, line := range lines {
+			ast.AddLeadingComments(node, ast.Comment(strings.TrimRight("// "+line, " ")))
+		}
+		return
+	}
+
+	for i, line := range lines {
+		lines[i] = strings.ReplaceAll(line, "*/", "* /")
+	}
+	if len(lines) == 1 {
+		ast.AddLeadingComments(node, ast.Comment("/* "+lines[0]+" */"))
+		return
+	}
+
+	// The printer writes comments verbatim, so continuation lines carry the
+	// indentation of the member they document.
+	prefix := "\n" + strings.Repeat(e.indent, len(e.path.Segments()))
+	var b strings.Builder
+	b.WriteString("/*")
+	for _, line := range lines {
+		b.WriteString(strings.TrimRight(prefix+" * "+line, " "))
+	}
+	b.WriteString(prefix + " */")
+	ast.AddLeadingComments(node, ast.Comment(b.String()))
+}
+
+// enter marks the map, slice or pointer v as being encoded, failing if it
+// already is.
+func (e *encoder) enter(v reflect.Value) error {
+	key := visitOf(v)
+	if e.visited[key] {
+		return e.errorf("encountered a cycle via %s", v.Type())
+	}
+	e.visited[key] = true
+	return nil
+}
+
+func (e *encoder) leave(v reflect.Value) {
+	delete(e.visited, visitOf(v))
+}
+
+func visitOf(v reflect.Value) visit {
+	key := visit{v.Pointer(), v.Type(), 0}
+	if v.Kind() == reflect.Slice {
+		key.len = v.Len()
+	}
+	return key
+}
+
+func (e *encoder) errorf(format string, args ...any) error {
+	at := e.path.String()
+	if at == "" {
+		at = "$"
+	}
+	return fmt.Errorf("json5: %s at %s", fmt.Sprintf(format, args...), at)
+}
+
+// isEmpty reports whether v is omitted by omitempty.
+func isEmpty(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64,
+		reflect.Interface, reflect.Pointer:
+		return v.IsZero()
+	}
+	return false
+}
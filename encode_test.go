@@ -0,0 +1,136 @@
+package json5
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+type server struct {
+	Host    string            `json:"host" comment:"Address to bind to."`
+	Port    int               `json:"port,omitempty" comment:"Port to listen on.\nUse 0 to pick a free port."`
+	Debug   bool              `json5:"debug" json:"verbose" doc:"Enables verbose logging."`
+	Tags    []string          `json:"tags"`
+	Limits  map[string]uint16 `json:"limits,omitempty"`
+	Timeout *float64          `json:"timeout"`
+	Data    []byte            `json:"data,omitempty"`
+	secret  string
+}
+
+type config struct {
+	server
+	Name    string    `json:"name" comment:"Name of the deployment."`
+	Backups []*server `json:"backups,omitempty"`
+	Skip    int       `json:"-"`
+}
+
+func TestMarshal(t *testing.T) {
+	ratio := math.Inf(-1)
+	tests := []struct {
+		name  string
+		value any
+		opts  []MarshalOption
+		want  string
+	}{
+		{"scalars", []any{nil, true, 1, -2.5, float32(0.1), "it's", math.NaN(), ratio}, nil,
+			`[null,true,1,-2.5,0.1,"it's",NaN,-Infinity]`},
+		{"map", map[int]string{10: "b", 2: "a"}, nil, `{"10":"b","2":"a"}`},
+		{"struct", config{
+			server: server{Host: "localhost", Tags: []string{"a"}, Data: []byte("hi"), secret: "x"},
+			Name:   "prod",
+		}, nil, `{"host":"localhost","debug":false,"tags":["a"],"timeout":null,"data":"aGk=","name":"prod"}`},
+		{"comments", config{
+			server:  server{Port: 8080},
+			Backups: []*server{{Host: "b"}},
+		}, []MarshalOption{Indent("  "), UnquotedKeys(), TrailingCommas(), Comments(LineComments)}, `{
+  // Address to bind to.
+  host: "",
+  // Port to listen on.
+  // Use 0 to pick a free port.
+  port: 8080,
+  // Enables verbose logging.
+  debug: false,
+  tags: null,
+  timeout: null,
+  // Name of the deployment.
+  name: "",
+  backups: [
+    {
+      // Address to bind to.
+      host: "b",
+      // Enables verbose logging.
+      debug: false,
+      tags: null,
+      timeout: null,
+    },
+  ],
+}
+`},
+		{"block comments", struct {
+			A int `json:"a" comment:"One line."`
+			B struct {
+				C int `json:"c" comment:"Two\nlines */"`
+			} `json:"b"`
+		}{}, []MarshalOption{Indent("\t"), Comments(BlockComments)}, "{\n" +
+			"\t/* One line. */\n" +
+			"\t\"a\": 0,\n" +
+			"\t\"b\": {\n" +
+			"\t\t/*\n" +
+			"\t\t * Two\n" +
+			"\t\t * lines * /\n" +
+			"\t\t */\n" +
+			"\t\t\"c\": 0\n" +
+			"\t}\n" +
+			"}\n"},
+		{"no comments when compact", server{Host: "h"}, []MarshalOption{Comments(LineComments)},
+			`{"host":"h","debug":false,"tags":null,"timeout":null}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Marshal(tt.value, tt.opts...)
+			if err != nil {
+				t.Fatalf("returned unexpected error %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("expected\n%s\ngot\n%s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestMarshal_Errors(t *testing.T) {
+	type node struct {
+		Next *node `json:"next"`
+	}
+	cycle := &node{}
+	cycle.Next = cycle
+	cyclicMap := map[string]any{}
+	cyclicMap["self"] = []any{cyclicMap}
+	cyclicSlice := []any{1, nil}
+	cyclicSlice[1] = cyclicSlice
+
+	tests := []struct {
+		name  string
+		value any
+		want  string
+	}{
+		{"channel", map[string]any{"a": []any{make(chan int)}}, "json5: unsupported type chan int at a[0]"},
+		{"map key", map[bool]int{true: 1}, "json5: unsupported map key type bool at $"},
+		{"cycle", cycle, "json5: encountered a cycle via *json5.node at next"},
+		{"map cycle", cyclicMap, "json5: encountered a cycle via map[string]interface {} at self[0]"},
+		{"slice cycle", cyclicSlice, "json5: encountered a cycle via []interface {} at [1]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Marshal(tt.value)
+			if err == nil {
+				t.Fatalf("expected error")
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected %q, got %q", tt.want, err.Error())
+			}
+		})
+	}
+}
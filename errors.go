@@ -0,0 +1,118 @@
+package json5
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Roundaround/json5-go/annotate"
+	"github.com/Roundaround/json5-go/ast"
+	"github.com/Roundaround/json5-go/path"
+	"github.com/Roundaround/json5-go/token"
+)
+
+var (
+	ErrUnknownField = errors.New("unknown field")
+	ErrMissingField = errors.New("missing required field")
+	ErrDuplicateKey = errors.New("duplicate key")
+)
+
+// DecodeError reports a value in a document that could not be decoded into
+// its Go destination.
+type DecodeError struct {
+	path *path.Path
+	node ast.Node
+	span token.Span
+	err  error
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("json5: %s %s: %v", e.pathString(), e.location(), e.err)
+}
+
+func (e *DecodeError) Unwrap() []error {
+	return []error{e.err}
+}
+
+// Path returns the path to the offending value within the document.
+func (e *DecodeError) Path() *path.Path {
+	return e.path
+}
+
+// Node returns the offending value.
+func (e *DecodeError) Node() ast.Node {
+	return e.node
+}
+
+// Span returns the location of the error, which is the key of the member for
+// errors about keys.
+func (e *DecodeError) Span() token.Span {
+	return e.span
+}
+
+func (e *DecodeError) Offset() int {
+	return e.span.Start.Offset
+}
+
+func (e *DecodeError) Line() int {
+	return e.span.Start.Line
+}
+
+func (e *DecodeError) Column() int {
+	return e.span.Start.Column
+}
+
+// Annotate renders the error beneath the offending line of source, the text
+// the document was parsed from, with a caret marking the value.
+func (e *DecodeError) Annotate(source string) string {
+	return fmt.Sprintf("json5: %s %s:\n%s", e.pathString(), e.location(), annotate.Line(source, e.Offset(), e.err.Error()))
+}
+
+func (e *DecodeError) pathString() string {
+	if e.path.IsEmpty() {
+		return path.Root
+	}
+	return e.path.String()
+}
+
+func (e *DecodeError) location() string {
+	if name := e.span.Start.Source; name != "" {
+		return fmt.Sprintf("in %s at ln %d, col %d", name, e.Line(), e.Column())
+	}
+	return fmt.Sprintf("at ln %d, col %d", e.Line(), e.Column())
+}
+
+// DecodeErrors holds every problem found while decoding a document, in source
+// order.
+type DecodeErrors struct {
+	errs []*DecodeError
+}
+
+func (e *DecodeErrors) Error() string {
+	messages := make([]string, len(e.errs))
+	for i, err := range e.errs {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "\n")
+}
+
+func (e *DecodeErrors) Unwrap() []error {
+	errs := make([]error, len(e.errs))
+	for i, err := range e.errs {
+		errs[i] = err
+	}
+	return errs
+}
+
+func (e *DecodeErrors) Errors() []*DecodeError {
+	return e.errs
+}
+
+// Annotate renders every error with DecodeError.Annotate.
+func (e *DecodeErrors) Annotate(source string) string {
+	annotated := make([]string, len(e.errs))
+	for i, err := range e.errs {
+		annotated[i] = err.Annotate(source)
+	}
+	return strings.Join(annotated, "\n\n")
+}
@@ -0,0 +1,169 @@
+// Package fields lists the fields of Go struct types as they appear in JSON5
+// documents, following the rules of encoding/json.
+package fields
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Field is a struct field that maps to an object member.
+type Field struct {
+	// Name is the member key, from the json5 or json tag or else the field
+	// name.
+	Name string
+	// Index is the index sequence for reflect.Value.FieldByIndex, which may
+	// pass through embedded structs.
+	Index     []int
+	Type      reflect.Type
+	OmitEmpty bool
+	// Required is set by the required tag option.
+	Required bool
+	Tag      reflect.StructTag
+
+	tagged bool
+}
+
+var cache sync.Map // map[reflect.Type][]Field
+
+// Of returns the fields of struct type t in declaration order. Fields of
+// embedded structs are promoted unless a shallower field has the same name;
+// conflicting fields at the same depth are dropped unless exactly one of them
+// is named by a tag.
+func Of(t reflect.Type) []Field {
+	if f, ok := cache.Load(t); ok {
+		return f.([]Field)
+	}
+	f, _ := cache.LoadOrStore(t, typeFields(t))
+	return f.([]Field)
+}
+
+// Lookup returns the tag value for key, preferring a json5 tag to a json tag.
+func Lookup(tag reflect.StructTag) (string, bool) {
+	if value, ok := tag.Lookup("json5"); ok {
+		return value, true
+	}
+	return tag.Lookup("json")
+}
+
+type embedded struct {
+	typ   reflect.Type
+	index []int
+}
+
+func typeFields(t reflect.Type) []Field {
+	var fields []Field
+	seen := make(map[string]bool)
+	visited := make(map[reflect.Type]bool)
+
+	current := []embedded{{typ: t}}
+	for len(current) > 0 {
+		var next []embedded
+		var level []Field
+
+		for _, e := range current {
+			if visited[e.typ] {
+				continue
+			}
+			visited[e.typ] = true
+
+			for i := range e.typ.NumField() {
+				sf := e.typ.Field(i)
+				ft := sf.Type
+				if ft.Kind() == reflect.Pointer {
+					ft = ft.Elem()
+				}
+				if sf.Anonymous {
+					if !sf.IsExported() && ft.Kind() != reflect.Struct {
+						continue
+					}
+				} else if !sf.IsExported() {
+					continue
+				}
+
+				tag, _ := Lookup(sf.Tag)
+				if tag == "-" {
+					continue
+				}
+				name, opts, _ := strings.Cut(tag, ",")
+				index := append(slices.Clone(e.index), i)
+
+				if name == "" && sf.Anonymous && ft.Kind() == reflect.Struct {
+					next = append(next, embedded{typ: ft, index: index})
+					continue
+				}
+
+				options := strings.Split(opts, ",")
+				f := Field{
+					Name:      name,
+					Index:     index,
+					Type:      sf.Type,
+					OmitEmpty: slices.Contains(options, "omitempty"),
+					Required:  slices.Contains(options, "required"),
+					Tag:       sf.Tag,
+					tagged:    name != "",
+				}
+				if f.Name == "" {
+					f.Name = sf.Name
+				}
+				level = append(level, f)
+			}
+		}
+
+		for _, f := range dominant(level) {
+			if !seen[f.Name] {
+				fields = append(fields, f)
+			}
+		}
+		for _, f := range level {
+			seen[f.Name] = true
+		}
+		current = next
+	}
+
+	slices.SortFunc(fields, func(a, b Field) int {
+		return slices.Compare(a.Index, b.Index)
+	})
+	return fields
+}
+
+// dominant resolves fields with the same name at the same depth.
+func dominant(level []Field) []Field {
+	byName := make(map[string][]Field)
+	for _, f := range level {
+		byName[f.Name] = append(byName[f.Name], f)
+	}
+
+	var fields []Field
+	for _, f := range level {
+		candidates := byName[f.Name]
+		if len(candidates) == 1 {
+			fields = append(fields, f)
+			continue
+		}
+		tagged := slices.DeleteFunc(slices.Clone(candidates), func(c Field) bool {
+			return !c.tagged
+		})
+		if len(tagged) == 1 && slices.Equal(tagged[0].Index, f.Index) {
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
+
+// Value returns the field within struct value v. It reports false if the
+// field is promoted through an embedded pointer that is nil.
+func (f Field) Value(v reflect.Value) (reflect.Value, bool) {
+	for i, x := range f.Index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
@@ -0,0 +1,66 @@
+package fields
+
+import (
+	"reflect"
+	"slices"
+	"testing"
+)
+
+type inner struct {
+	A string
+	B string `json:"b"`
+	C string
+}
+
+type other struct {
+	C string
+	D string `json5:"d" json:"dee"`
+}
+
+type outer struct {
+	inner
+	*other
+	A      int    `json:"a,omitempty"`
+	Skip   string `json:"-"`
+	Dash   string `json:"-,"`
+	hidden int
+}
+
+func TestOf(t *testing.T) {
+	fields := Of(reflect.TypeFor[outer]())
+
+	names := make([]string, 0, len(fields))
+	for _, f := range fields {
+		names = append(names, f.Name)
+	}
+	// C conflicts between the two embedded structs, so neither is kept
+	want := []string{"A", "b", "d", "a", "-"}
+	if !slices.Equal(names, want) {
+		t.Fatalf("expected fields %q, got %q", want, names)
+	}
+
+	if !slices.Equal(fields[1].Index, []int{0, 1}) {
+		t.Errorf("expected promoted field index [0 1], got %v", fields[1].Index)
+	}
+	if !fields[3].OmitEmpty || fields[3].Type.Kind() != reflect.Int {
+		t.Errorf("expected a to be an omitempty int")
+	}
+}
+
+func TestOf_Required(t *testing.T) {
+	fields := Of(reflect.TypeFor[struct {
+		A string `json:"a,required"`
+		B string `json:"b,omitempty,required"`
+		C string `json:"c"`
+	}]())
+
+	want := []bool{true, true, false}
+	for i, f := range fields {
+		if f.Required != want[i] {
+			t.Errorf("%s: expected required %v, got %v", f.Name, want[i], f.Required)
+		}
+	}
+	if !fields[1].OmitEmpty {
+		t.Errorf("expected b to keep omitempty")
+	}
+}
@@ -0,0 +1,7 @@
+// Package json5 converts between JSON5 documents and Go values.
+//
+// Struct fields are mapped to object members by their json5 tags, falling
+// back to json tags, following the same rules as encoding/json. Types may
+// customize their encoding by implementing Marshaler and Unmarshaler, or the
+// equivalent interfaces from encoding/json and encoding.
+package json5
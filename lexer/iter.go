@@ -0,0 +1,93 @@
+package lexer
+
+import (
+	"fmt"
+	"iter"
+
+	"github.com/Roundaround/json5-go/token"
+)
+
+// All returns an iterator over the remaining tokens. Iteration ends before the
+// EOF token, or immediately after the first ILLEGAL token.
+func (l *Lexer) All() iter.Seq[token.Token] {
+	return func(yield func(token.Token) bool) {
+		for {
+			tok := l.NextToken()
+			if tok.Kind == token.EOF {
+				return
+			}
+			if !yield(tok) || tok.Kind == token.ILLEGAL {
+				return
+			}
+		}
+	}
+}
+
+// Significant is like All, but skips line and block comments.
+func (l *Lexer) Significant() iter.Seq[token.Token] {
+	return func(yield func(token.Token) bool) {
+		for tok := range l.All() {
+			if tok.Kind.IsComment() {
+				continue
+			}
+			if !yield(tok) {
+				return
+			}
+		}
+	}
+}
+
+// Tokenize lexes the entire source, returning every token up to but excluding
+// EOF. If an illegal token is encountered, the tokens read so far are returned
+// along with a *TokenError.
+func Tokenize(source string) ([]token.Token, error) {
+	tokens := make([]token.Token, 0)
+	for tok := range New(source).All() {
+		if tok.Kind == token.ILLEGAL {
+			return tokens, &TokenError{tok}
+		}
+		tokens = append(tokens, tok)
+	}
+	return tokens, nil
+}
+
+type TokenError struct {
+	Token token.Token
+}
+
+func (e *TokenError) Error() string {
+	return fmt.Sprintf("illegal token %q at line %d, column %d", e.Token.Literal, e.Token.Line, e.Token.Column)
+}
+
+// Peekable wraps a Lexer with an arbitrary amount of lookahead.
+type Peekable struct {
+	lexer *Lexer
+	buf   []token.Token
+}
+
+func NewPeekable(l *Lexer) *Peekable {
+	return &Peekable{lexer: l}
+}
+
+func (p *Peekable) NextToken() token.Token {
+	if len(p.buf) > 0 {
+		tok := p.buf[0]
+		p.buf = p.buf[1:]
+		return tok
+	}
+	return p.lexer.NextToken()
+}
+
+// Peek returns the next token without consuming it.
+func (p *Peekable) Peek() token.Token {
+	return p.PeekN(0)
+}
+
+// PeekN returns the token n positions ahead of the next token without
+// consuming anything. PeekN(0) is equivalent to Peek.
+func (p *Peekable) PeekN(n int) token.Token {
+	for len(p.buf) <= n {
+		p.buf = append(p.buf, p.lexer.NextToken())
+	}
+	return p.buf[n]
+}
@@ -4,29 +4,72 @@ import (
 	"errors"
 	"strconv"
 	"strings"
+	"unicode/utf16"
 	"unicode/utf8"
+	"unsafe"
 
 	"github.com/Roundaround/json5-go/token"
 )
 
-func New(source string) *Lexer {
+func New(source string, opts ...Option) *Lexer {
 	l := &Lexer{
 		source: source,
 		col:    -1,
 	}
+	for _, opt := range opts {
+		opt(l)
+	}
 	l.readChar()
 	return l
 }
 
+// NewBytes creates a Lexer that reads directly from source without copying it.
+// Token literals may reference the underlying array, so source must not be
+// modified while the lexer or any of its tokens are in use.
+func NewBytes(source []byte, opts ...Option) *Lexer {
+	return New(unsafe.String(unsafe.SliceData(source), len(source)), opts...)
+}
+
+type Option func(*Lexer)
+
+// DeferUnescape leaves quoted string literals exactly as they appear in the
+// source. Use Unescape to decode them when needed.
+func DeferUnescape() Option {
+	return func(l *Lexer) {
+		l.deferUnescape = true
+	}
+}
+
+// Columns sets the unit used when counting token columns. The default is
+// ByteColumns.
+func Columns(unit ColumnUnit) Option {
+	return func(l *Lexer) {
+		l.unit = unit
+	}
+}
+
+// SourceName sets the name of the file or URI being read, which is recorded on
+// every token and position.
+func SourceName(name string) Option {
+	return func(l *Lexer) {
+		l.name = name
+	}
+}
+
 type Lexer struct {
-	source  string
-	pos     int
-	readPos int
-	line    int
-	col     int
-	ch      rune
-	next    rune
-	tokPos  tokenPos
+	source        string
+	name          string
+	pos           int
+	readPos       int
+	line          int
+	col           int
+	ch            rune
+	width         int
+	next          rune
+	tokPos        tokenPos
+	end           tokenPos
+	unit          ColumnUnit
+	deferUnescape bool
 }
 
 type tokenPos struct {
@@ -81,6 +124,7 @@ func (l *Lexer) NextToken() token.Token {
 			tok = l.readIdentifierToken()
 		} else {
 			tok = l.rtoken(token.ILLEGAL)
+			l.readChar()
 		}
 	}
 
@@ -88,13 +132,31 @@ func (l *Lexer) NextToken() token.Token {
 		l.readChar()
 	}
 
+	tok.Source = l.name
+	if tok.Kind == token.EOF {
+		tok.End = tok.Start()
+	} else {
+		tok.Raw = l.source[tok.Offset:l.end.offset]
+		tok.End = token.Position{
+			Source: l.name,
+			Offset: l.end.offset,
+			Line:   l.end.line + 1,
+			Column: l.end.column + 1,
+		}
+	}
+
 	return tok
 }
 
 func (l *Lexer) rtoken(kind token.Kind) token.Token {
+	literal := eof
+	if l.ch != 0 {
+		_, size := utf8.DecodeRuneInString(l.source[l.pos:])
+		literal = l.source[l.pos : l.pos+size]
+	}
 	return token.Token{
 		Kind:    kind,
-		Literal: string(l.ch),
+		Literal: literal,
 		Offset:  l.tokPos.offset,
 		Line:    l.tokPos.line + 1,
 		Column:  l.tokPos.column + 1,
@@ -118,15 +180,30 @@ func (l *Lexer) skipWhitespace() {
 }
 
 func (l *Lexer) readChar() {
+	// Record where the current character ends before moving past it, so that
+	// tokens can report the position just after their final character.
+	l.end = tokenPos{
+		offset: l.readPos,
+		line:   l.line,
+		column: l.col,
+	}
+	if isLineTerminator(l.ch) {
+		l.end.column = 0
+	} else {
+		l.end.column += l.width
+	}
+
 	if l.readPos >= len(l.source) {
 		l.pos = l.readPos
 		l.ch = 0
-		l.col = 0
+		l.width = 0
+		l.col = max(l.end.column, 0)
 		return
 	}
 
 	r, size := utf8.DecodeRuneInString(l.source[l.readPos:])
 	l.ch = r
+	l.width = l.unit.width(r, size)
 	l.pos = l.readPos
 	l.readPos += size
 
@@ -145,7 +222,7 @@ func (l *Lexer) readChar() {
 			l.readPos += snext
 		}
 	} else {
-		l.col += len(string(l.ch))
+		l.col += l.width
 	}
 }
 
@@ -155,26 +232,27 @@ func (l *Lexer) readString() (string, error) {
 	l.readChar()
 
 	for l.ch != q {
-		l.readChar()
-
-		if l.ch == 0 {
+		if l.ch == 0 || isLineTerminator(l.ch) {
 			return "", errors.New("unterminated string")
 		}
 
 		if l.ch == '\\' {
-			if isLineTerminator(l.next) {
-				l.readChar()
+			// Skip the escaped character, which may be a quote or a line
+			// continuation
+			l.readChar()
+			if l.ch == 0 {
+				return "", errors.New("unterminated string")
 			}
-			continue
 		}
 
-		if isLineTerminator(l.ch) {
-			return "", errors.New("unterminated string")
-		}
+		l.readChar()
 	}
 
 	l.readChar()
-	return unescapeString(l.source[pos:l.pos])
+	if l.deferUnescape {
+		return l.source[pos:l.pos], nil
+	}
+	return Unescape(l.source[pos:l.pos])
 }
 
 func (l *Lexer) readCommentToken() token.Token {
@@ -182,57 +260,74 @@ func (l *Lexer) readCommentToken() token.Token {
 	case '/':
 		return l.token(token.LINE_COMMENT, l.readLineComment())
 	case '*':
-		return l.token(token.BLOCK_COMMENT, l.readBlockComment())
+		literal, err := l.readBlockComment()
+		if err != nil {
+			return l.rtoken(token.ILLEGAL)
+		}
+		return l.token(token.BLOCK_COMMENT, literal)
 	default:
-		return l.rtoken(token.ILLEGAL)
+		tok := l.rtoken(token.ILLEGAL)
+		l.readChar()
+		return tok
 	}
 }
 
 func (l *Lexer) readLineComment() string {
 	pos := l.pos
-	for !isLineTerminator(l.ch) {
+	for l.ch != 0 && !isLineTerminator(l.ch) {
 		l.readChar()
 	}
 	return l.source[pos:l.pos]
 }
 
-func (l *Lexer) readBlockComment() string {
+func (l *Lexer) readBlockComment() (string, error) {
 	pos := l.pos
+	l.readChar() // skip '/'
+	l.readChar() // skip '*'
 	for !(l.ch == '*' && l.next == '/') {
+		if l.ch == 0 {
+			return "", errors.New("unterminated block comment")
+		}
 		l.readChar()
 	}
 	l.readChar()
 	l.readChar()
-	return l.source[pos:l.pos]
+	return l.source[pos:l.pos], nil
 }
 
 func (l *Lexer) readNumberToken() token.Token {
-	sign := ""
+	pos := l.pos
 	if l.ch == '-' || l.ch == '+' {
-		sign = string(l.ch)
 		l.readChar()
 	}
 
-	if l.ch == '0' && l.next == 'x' {
-		return l.token(token.HEX_NUMBER, l.readHexNumber(sign))
+	if pos != l.pos && isIdentifierStart(l.ch) {
+		// Signed Infinity or NaN
+		kind, ok := token.LookupKeyword(l.readIdentifier())
+		if ok && (kind == token.INFINITY || kind == token.NAN) {
+			return l.token(kind, l.source[pos:l.pos])
+		}
+		return l.token(token.ILLEGAL, l.source[pos:l.pos])
+	}
+
+	if l.ch == '0' && (l.next == 'x' || l.next == 'X') {
+		l.readHexNumber()
+		return l.token(token.HEX_NUMBER, l.source[pos:l.pos])
 	}
 
-	return l.token(token.DECIMAL_NUMBER, l.readDecimalNumber(sign))
+	l.readDecimalNumber()
+	return l.token(token.DECIMAL_NUMBER, l.source[pos:l.pos])
 }
 
-func (l *Lexer) readHexNumber(sign string) string {
-	pos := l.pos
+func (l *Lexer) readHexNumber() {
 	l.readChar()
 	l.readChar()
 	for isHexDigit(l.ch) {
 		l.readChar()
 	}
-	return sign + l.source[pos:l.pos]
 }
 
-func (l *Lexer) readDecimalNumber(sign string) string {
-	pos := l.pos
-
+func (l *Lexer) readDecimalNumber() {
 	// integer part ([0-9]*)
 	for isDigit(l.ch) {
 		l.readChar()
@@ -256,8 +351,6 @@ func (l *Lexer) readDecimalNumber(sign string) string {
 			l.readChar()
 		}
 	}
-
-	return sign + l.source[pos:l.pos]
 }
 
 func (l *Lexer) readIdentifierToken() token.Token {
@@ -277,6 +370,8 @@ func (l *Lexer) readIdentifier() string {
 	return l.source[pos:l.pos]
 }
 
+const eof = "\x00"
+
 func isWhitespace(ch rune) bool {
 	return ch == ' ' || ch == '\t' || isLineTerminator(ch)
 }
@@ -305,8 +400,18 @@ func isIdentifierPart(ch rune) bool {
 	return (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z') || (ch >= '0' && ch <= '9') || ch == '_' || ch == '$'
 }
 
-func unescapeString(s string) (string, error) {
+// Unescape decodes the escape sequences in a quoted string literal. If the
+// literal contains no escapes it is returned as-is, without allocating.
+func Unescape(s string) (string, error) {
+	if strings.IndexByte(s, '\\') < 0 {
+		if !utf8.ValidString(s) {
+			return "", errors.New("invalid UTF-8 sequence")
+		}
+		return s, nil
+	}
+
 	var buf strings.Builder
+	buf.Grow(len(s))
 	pos := 0
 
 	for pos < len(s) {
@@ -351,21 +456,55 @@ func unescapeString(s string) (string, error) {
 			case 'f':
 				buf.WriteRune('\f')
 				pos += psize
+			case 'v':
+				buf.WriteRune('\v')
+				pos += psize
+			case '0':
+				if pos+psize < len(s) && isDigit(rune(s[pos+psize])) {
+					return "", errors.New("invalid null escape sequence")
+				}
+				buf.WriteByte(0)
+				pos += psize
+			case 'x':
+				// Convert \xXX to the actual rune
+				ru, err := parseHex(s, pos+psize, 2)
+				if err != nil {
+					return "", errors.New("invalid hexadecimal escape sequence")
+				}
+				buf.WriteRune(ru)
+				pos += psize + 2
 			case 'u', 'U':
 				// Convert \uXXXX to the actual rune
-				hex := s[pos+1 : pos+5]
-				ru, err := strconv.ParseUint(hex, 16, 32)
+				ru, err := parseHex(s, pos+psize, 4)
 				if err != nil {
 					return "", errors.New("invalid Unicode escape sequence")
 				}
-				buf.WriteRune(rune(ru))
 				pos += psize + 4
-			case '\n', '\r', '\u2028', '\u2029':
-				buf.WriteRune(pr)
+				// Combine a surrogate pair written as two escapes
+				if utf16.IsSurrogate(ru) && strings.HasPrefix(s[pos:], `\u`) {
+					if lo, err := parseHex(s, pos+2, 4); err == nil {
+						if combined := utf16.DecodeRune(ru, lo); combined != utf8.RuneError {
+							ru = combined
+							pos += 6
+						}
+					}
+				}
+				buf.WriteRune(ru)
+			case '\n', '\u2028', '\u2029':
+				// Line continuations add nothing to the value
+				pos += psize
+			case '\r':
 				pos += psize
+				if pos < len(s) && s[pos] == '\n' {
+					pos++
+				}
 			default:
-				// Invalid escape sequence - leave as is
-				buf.WriteRune(r)
+				if isDigit(pr) {
+					return "", errors.New("invalid escape sequence")
+				}
+				// Any other character escapes itself
+				buf.WriteRune(pr)
+				pos += psize
 			}
 		} else {
 			buf.WriteRune(r)
@@ -374,3 +513,15 @@ func unescapeString(s string) (string, error) {
 
 	return buf.String(), nil
 }
+
+// parseHex parses the n hexadecimal digits at s[pos:].
+func parseHex(s string, pos, n int) (rune, error) {
+	if pos+n > len(s) {
+		return 0, errors.New("unexpected end of escape sequence")
+	}
+	ru, err := strconv.ParseUint(s[pos:pos+n], 16, 32)
+	if err != nil {
+		return 0, err
+	}
+	return rune(ru), nil
+}
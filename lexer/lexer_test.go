@@ -1,8 +1,12 @@
 package lexer
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"iter"
 	"os"
+	"slices"
 	"strings"
 	"testing"
 
@@ -77,7 +81,7 @@ func TestLexer_NextToken(t *testing.T) {
 				{token.COMMA, ",", 6, 38},
 				{token.UNQUOTED_STRING, "lineBreaks", 7, 3},
 				{token.COLON, ":", 7, 13},
-				{token.QUOTED_STRING, fmt.Sprintf("\"Look, Mom! %sNo \\n's!\"", tt.linebreak), 7, 15},
+				{token.QUOTED_STRING, "\"Look, Mom! No \\n's!\"", 7, 15},
 				{token.COMMA, ",", 8, 11},
 				{token.UNQUOTED_STRING, "hexadecimal", 9, 3},
 				{token.COLON, ":", 9, 14},
@@ -153,3 +157,401 @@ func TestLexer_NextToken(t *testing.T) {
 		})
 	}
 }
+
+func TestLexer_All(t *testing.T) {
+	source := "{ // comment\n  a: [1, /* two */ 'three'] }"
+
+	kinds := func(seq iter.Seq[token.Token]) []token.Kind {
+		got := make([]token.Kind, 0)
+		for tok := range seq {
+			got = append(got, tok.Kind)
+		}
+		return got
+	}
+
+	all := kinds(New(source).All())
+	want := []token.Kind{
+		token.LEFT_BRACE,
+		token.LINE_COMMENT,
+		token.UNQUOTED_STRING,
+		token.COLON,
+		token.LEFT_BRACKET,
+		token.DECIMAL_NUMBER,
+		token.COMMA,
+		token.BLOCK_COMMENT,
+		token.QUOTED_STRING,
+		token.RIGHT_BRACKET,
+		token.RIGHT_BRACE,
+	}
+	if !slices.Equal(all, want) {
+		t.Errorf("All: expected %v, got %v", want, all)
+	}
+
+	significant := kinds(New(source).Significant())
+	want = slices.DeleteFunc(want, token.Kind.IsComment)
+	if !slices.Equal(significant, want) {
+		t.Errorf("Significant: expected %v, got %v", want, significant)
+	}
+}
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		source string
+		count  int
+		err    bool
+	}{
+		{"", 0, false},
+		{"{}", 2, false},
+		{"[1, 2] // trailing comment", 6, false},
+		{"[1, 2] /* unterminated", 5, true},
+		{"['unterminated]", 1, true},
+		{"{a: #}", 3, true},
+		{`'\u1'`, 0, true},
+		{`'\x4'`, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%q", tt.source), func(t *testing.T) {
+			tokens, err := Tokenize(tt.source)
+			if len(tokens) != tt.count {
+				t.Errorf("expected %d tokens, got %d", tt.count, len(tokens))
+			}
+			if tt.err {
+				var terr *TokenError
+				if !errors.As(err, &terr) {
+					t.Fatalf("expected *TokenError, got %v", err)
+				}
+				if terr.Token.Kind != token.ILLEGAL {
+					t.Errorf("expected %s token, got %s", token.ILLEGAL, terr.Token.Kind)
+				}
+			} else if err != nil {
+				t.Fatalf("returned unexpected error %v", err)
+			}
+		})
+	}
+}
+
+func TestPeekable(t *testing.T) {
+	p := NewPeekable(New("[1, 2]"))
+
+	if tok := p.PeekN(3); tok.Kind != token.DECIMAL_NUMBER || tok.Literal != "2" {
+		t.Fatalf("PeekN(3): expected %s %q, got %s", token.DECIMAL_NUMBER, "2", tok)
+	}
+	if tok := p.Peek(); tok.Kind != token.LEFT_BRACKET {
+		t.Fatalf("Peek: expected %s, got %s", token.LEFT_BRACKET, tok)
+	}
+
+	want := []token.Kind{
+		token.LEFT_BRACKET,
+		token.DECIMAL_NUMBER,
+		token.COMMA,
+		token.DECIMAL_NUMBER,
+		token.RIGHT_BRACKET,
+		token.EOF,
+		token.EOF,
+	}
+	for i, kind := range want {
+		if tok := p.NextToken(); tok.Kind != kind {
+			t.Fatalf("token %d: expected %s, got %s", i, kind, tok.Kind)
+		}
+	}
+}
+
+func TestLexer_DeferUnescape(t *testing.T) {
+	source := []byte(`["plain", 'esc\u01E8ped']`)
+
+	tokens := make([]token.Token, 0)
+	for tok := range NewBytes(source, DeferUnescape()).All() {
+		if tok.Kind == token.QUOTED_STRING {
+			tokens = append(tokens, tok)
+		}
+	}
+
+	want := []struct {
+		literal   string
+		unescaped string
+	}{
+		{`"plain"`, `"plain"`},
+		{`'esc\u01E8ped'`, `'escǨped'`},
+	}
+	if len(tokens) != len(want) {
+		t.Fatalf("expected %d strings, got %d", len(want), len(tokens))
+	}
+	for i, tok := range tokens {
+		if tok.Literal != want[i].literal {
+			t.Errorf("expected literal %q, got %q", want[i].literal, tok.Literal)
+		}
+		unescaped, err := Unescape(tok.Literal)
+		if err != nil {
+			t.Fatalf("returned unexpected error %v", err)
+		}
+		if unescaped != want[i].unescaped {
+			t.Errorf("expected unescaped %q, got %q", want[i].unescaped, unescaped)
+		}
+	}
+}
+
+func BenchmarkLexer(b *testing.B) {
+	source, err := os.ReadFile("testdata/test.json5")
+	if err != nil {
+		b.Fatalf("failed to read test file: %v", err)
+	}
+	source = bytes.Repeat(source, 100)
+
+	benchmarks := []struct {
+		name string
+		new  func([]byte) *Lexer
+	}{
+		{"string", func(source []byte) *Lexer { return New(string(source)) }},
+		{"bytes", func(source []byte) *Lexer { return NewBytes(source) }},
+		{"bytes-deferred", func(source []byte) *Lexer { return NewBytes(source, DeferUnescape()) }},
+	}
+
+	for _, bm := range benchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			b.ReportAllocs()
+			b.SetBytes(int64(len(source)))
+			for b.Loop() {
+				lexer := bm.new(source)
+				for tok := lexer.NextToken(); tok.Kind != token.EOF; tok = lexer.NextToken() {
+				}
+			}
+		})
+	}
+}
+
+func TestLexer_TokenSpans(t *testing.T) {
+	span := func(startLine, startColumn, endLine, endColumn int) token.Span {
+		return token.Span{
+			Start: token.Position{Line: startLine, Column: startColumn},
+			End:   token.Position{Line: endLine, Column: endColumn},
+		}
+	}
+
+	tests := []struct {
+		name   string
+		source string
+		want   []token.Span
+	}{
+		{
+			name:   "punctuation",
+			source: "{ a: 1 }",
+			want: []token.Span{
+				span(1, 1, 1, 2),
+				span(1, 3, 1, 4),
+				span(1, 4, 1, 5),
+				span(1, 6, 1, 7),
+				span(1, 8, 1, 9),
+				span(1, 9, 1, 9),
+			},
+		},
+		{
+			name:   "line continuation",
+			source: "'multi\\\nline' // comment\n",
+			want: []token.Span{
+				span(1, 1, 2, 6),
+				span(2, 7, 2, 17),
+				span(3, 1, 3, 1),
+			},
+		},
+		{
+			name:   "block comment",
+			source: "/* block\r\n   comment */ null",
+			want: []token.Span{
+				span(1, 1, 2, 14),
+				span(2, 15, 2, 19),
+				span(2, 19, 2, 19),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lexer := New(tt.source)
+			for i, want := range tt.want {
+				tok := lexer.NextToken()
+				got := tok.Span()
+				if got.Start.Line != want.Start.Line || got.Start.Column != want.Start.Column ||
+					got.End.Line != want.End.Line || got.End.Column != want.End.Column {
+					t.Errorf("token %d (%s): expected span %s, got %s", i, tok, want, got)
+				}
+				if got.Len() != len(tok.Literal) && tok.Kind != token.EOF && tok.Kind != token.QUOTED_STRING {
+					t.Errorf("token %d (%s): expected span length %d, got %d", i, tok, len(tok.Literal), got.Len())
+				}
+			}
+		})
+	}
+}
+
+func TestLexer_Columns(t *testing.T) {
+	source := "{'Ǩ😀': 1}"
+
+	tests := []struct {
+		unit   ColumnUnit
+		column int
+	}{
+		{ByteColumns, 10},
+		{RuneColumns, 6},
+		{UTF16Columns, 7},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.unit.String(), func(t *testing.T) {
+			lexer := New(source, Columns(tt.unit))
+			for range 2 {
+				lexer.NextToken()
+			}
+			if tok := lexer.NextToken(); tok.Kind != token.COLON || tok.Column != tt.column {
+				t.Errorf("expected %s at col %d, got %s at col %d", token.COLON, tt.column, tok, tok.Column)
+			}
+		})
+	}
+}
+
+func TestLineIndex(t *testing.T) {
+	source := "a\r\nǨ😀b\n\nc"
+	index := NewLineIndex(source)
+
+	if index.LineCount() != 4 {
+		t.Fatalf("expected 4 lines, got %d", index.LineCount())
+	}
+
+	// The 'b' on the second line
+	offset := strings.IndexByte(source, 'b')
+	columns := map[ColumnUnit]int{
+		ByteColumns:  7,
+		RuneColumns:  3,
+		UTF16Columns: 4,
+	}
+
+	for unit, column := range columns {
+		pos, err := index.Position(offset, unit)
+		if err != nil {
+			t.Fatalf("%s: returned unexpected error %v", unit, err)
+		}
+		if pos.Line != 2 || pos.Column != column {
+			t.Errorf("%s: expected ln 2, col %d, got ln %d, col %d", unit, column, pos.Line, pos.Column)
+		}
+
+		got, err := index.Offset(2, column, unit)
+		if err != nil {
+			t.Fatalf("%s: returned unexpected error %v", unit, err)
+		}
+		if got != offset {
+			t.Errorf("%s: expected offset %d, got %d", unit, offset, got)
+		}
+	}
+
+	if got, err := index.ConvertColumn(2, 4, UTF16Columns, ByteColumns); err != nil || got != 7 {
+		t.Errorf("expected col 7, got %d (%v)", got, err)
+	}
+	if _, err := index.ConvertColumn(2, 3, UTF16Columns, ByteColumns); err == nil {
+		t.Errorf("expected error for column inside a surrogate pair")
+	}
+	if _, err := index.Offset(3, 2, ByteColumns); err == nil {
+		t.Errorf("expected error for column past the end of an empty line")
+	}
+	if got, err := index.Offset(4, 2, ByteColumns); err != nil || got != len(source) {
+		t.Errorf("expected offset %d, got %d (%v)", len(source), got, err)
+	}
+}
+
+func TestLexer_Raw(t *testing.T) {
+	tests := []struct {
+		source  string
+		literal string
+		raw     string
+	}{
+		{`'\u01E8'`, `'Ǩ'`, `'\u01E8'`},
+		{`'Ǩ'`, `'Ǩ'`, `'Ǩ'`},
+		{`'\''`, `'''`, `'\''`},
+		{`"say \"hi\""`, `"say "hi""`, `"say \"hi\""`},
+		{"'line \\\ncontinuation'", "'line continuation'", "'line \\\ncontinuation'"},
+		{"'line \\\r\ncontinuation'", "'line continuation'", "'line \\\r\ncontinuation'"},
+		{"0xC0FFEE", "0xC0FFEE", "0xC0FFEE"},
+		{"-0XC0FFEE", "-0XC0FFEE", "-0XC0FFEE"},
+		{"-.5e+3", "-.5e+3", "-.5e+3"},
+		{"// comment", "// comment", "// comment"},
+		{"-Infinity", "-Infinity", "-Infinity"},
+		{"+NaN", "+NaN", "+NaN"},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%q", tt.source), func(t *testing.T) {
+			tok := New(tt.source).NextToken()
+			if tok.Literal != tt.literal {
+				t.Errorf("expected literal %q, got %q", tt.literal, tok.Literal)
+			}
+			if tok.Raw != tt.raw {
+				t.Errorf("expected raw %q, got %q", tt.raw, tok.Raw)
+			}
+		})
+	}
+}
+
+func TestLexer_SourceName(t *testing.T) {
+	l := New("[1,\n 2]", SourceName("file:///etc/app.json5"))
+	for {
+		tok := l.NextToken()
+		if tok.Source != "file:///etc/app.json5" || tok.End.Source != tok.Source {
+			t.Errorf("%s: expected source on token and end, got %q and %q", tok, tok.Source, tok.End.Source)
+		}
+		if tok.Kind == token.DECIMAL_NUMBER && tok.Literal == "2" {
+			if got := tok.Start().String(); got != "file:///etc/app.json5:2:2" {
+				t.Errorf("expected position file:///etc/app.json5:2:2, got %s", got)
+			}
+		}
+		if tok.Kind == token.EOF {
+			break
+		}
+	}
+}
+
+func TestUnescape(t *testing.T) {
+	tests := []struct {
+		source string
+		want   string
+		err    bool
+	}{
+		{`plain`, "plain", false},
+		{`a\nb\tc`, "a\nb\tc", false},
+		{`\u0041\u00e9`, "Aé", false},
+		{`\x41\xe9`, "Aé", false},
+		{`a\0b`, "a\x00b", false},
+		{`a\vb`, "a\vb", false},
+		{`\q\-\ `, "q- ", false},
+		{`\uD83D\uDE00`, "\U0001F600", false},
+		{`\ud83d\ude00!`, "\U0001F600!", false},
+		{`\uD83D`, "\uFFFD", false},
+		{`\uD83Dx`, "\uFFFDx", false},
+		{`\1`, "", true},
+		{"a\\\nb", "ab", false},
+		{"a\\\r\nb", "ab", false},
+		{"a\\\rb", "ab", false},
+		{"a\\\u2028b", "ab", false},
+		{`\01`, "", true},
+		{`\u1`, "", true},
+		{`\u12`, "", true},
+		{`\uZZZZ`, "", true},
+		{`\x`, "", true},
+		{`\x4G`, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%q", tt.source), func(t *testing.T) {
+			got, err := Unescape(tt.source)
+			if tt.err {
+				if err == nil {
+					t.Fatalf("expected error, got %q", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("returned unexpected error %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
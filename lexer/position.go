@@ -0,0 +1,160 @@
+package lexer
+
+import (
+	"fmt"
+	"sort"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/Roundaround/json5-go/token"
+)
+
+type ColumnUnit int
+
+const (
+	// ByteColumns counts columns in UTF-8 bytes.
+	ByteColumns ColumnUnit = iota
+	// RuneColumns counts columns in Unicode code points.
+	RuneColumns
+	// UTF16Columns counts columns in UTF-16 code units, as used by most editors
+	// and the Language Server Protocol.
+	UTF16Columns
+)
+
+func (u ColumnUnit) String() string {
+	switch u {
+	case ByteColumns:
+		return "Bytes"
+	case RuneColumns:
+		return "Runes"
+	case UTF16Columns:
+		return "UTF-16"
+	default:
+		return "Unknown"
+	}
+}
+
+// width returns the number of columns occupied by a rune that is size bytes
+// long in the source.
+func (u ColumnUnit) width(ch rune, size int) int {
+	switch u {
+	case RuneColumns:
+		return 1
+	case UTF16Columns:
+		if n := utf16.RuneLen(ch); n > 0 {
+			return n
+		}
+		return 1
+	default:
+		return size
+	}
+}
+
+// LineIndex converts between byte offsets and line/column positions in a
+// source, with columns counted in any ColumnUnit. Line terminators are
+// recognized the same way the lexer recognizes them.
+type LineIndex struct {
+	source string
+	lines  []int
+}
+
+func NewLineIndex(source string) *LineIndex {
+	lines := []int{0}
+	for i := 0; i < len(source); {
+		r, size := utf8.DecodeRuneInString(source[i:])
+		i += size
+		if !isLineTerminator(r) {
+			continue
+		}
+		if r == '\r' && i < len(source) && source[i] == '\n' {
+			i++
+		}
+		lines = append(lines, i)
+	}
+	return &LineIndex{source: source, lines: lines}
+}
+
+func (x *LineIndex) LineCount() int {
+	return len(x.lines)
+}
+
+// Position returns the one-based line and column of the given byte offset.
+func (x *LineIndex) Position(offset int, unit ColumnUnit) (token.Position, error) {
+	if offset < 0 || offset > len(x.source) {
+		return token.Position{}, fmt.Errorf("offset %d out of range", offset)
+	}
+
+	line := sort.Search(len(x.lines), func(i int) bool {
+		return x.lines[i] > offset
+	}) - 1
+
+	column := 0
+	for i := x.lines[line]; i < offset; {
+		r, size := utf8.DecodeRuneInString(x.source[i:])
+		if i+size > offset {
+			return token.Position{}, fmt.Errorf("offset %d is inside a multi-byte character", offset)
+		}
+		column += unit.width(r, size)
+		i += size
+	}
+
+	return token.Position{Offset: offset, Line: line + 1, Column: column + 1}, nil
+}
+
+// Offset returns the byte offset of the given one-based line and column.
+// A column just past the end of the line is allowed.
+func (x *LineIndex) Offset(line, column int, unit ColumnUnit) (int, error) {
+	if line < 1 || line > len(x.lines) {
+		return 0, fmt.Errorf("line %d out of range", line)
+	}
+
+	offset := x.lines[line-1]
+	end := x.lineEnd(line - 1)
+	for col := 1; col < column; {
+		if offset >= end {
+			return 0, fmt.Errorf("column %d out of range on line %d", column, line)
+		}
+		r, size := utf8.DecodeRuneInString(x.source[offset:])
+		col += unit.width(r, size)
+		offset += size
+		if col > column {
+			return 0, fmt.Errorf("column %d is inside a character on line %d", column, line)
+		}
+	}
+
+	if column < 1 {
+		return 0, fmt.Errorf("column %d out of range on line %d", column, line)
+	}
+	return offset, nil
+}
+
+// ConvertColumn converts a one-based column on the given line from one unit
+// to another.
+func (x *LineIndex) ConvertColumn(line, column int, from, to ColumnUnit) (int, error) {
+	offset, err := x.Offset(line, column, from)
+	if err != nil {
+		return 0, err
+	}
+	pos, err := x.Position(offset, to)
+	if err != nil {
+		return 0, err
+	}
+	return pos.Column, nil
+}
+
+// lineEnd returns the offset of the terminator ending the given zero-based
+// line, or the end of the source for the last line.
+func (x *LineIndex) lineEnd(line int) int {
+	if line+1 >= len(x.lines) {
+		return len(x.source)
+	}
+	end := x.lines[line+1]
+	for end > x.lines[line] {
+		r, size := utf8.DecodeLastRuneInString(x.source[:end])
+		if !isLineTerminator(r) {
+			break
+		}
+		end -= size
+	}
+	return end
+}
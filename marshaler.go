@@ -0,0 +1,102 @@
+package json5
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+
+	"github.com/Roundaround/json5-go/ast"
+	"github.com/Roundaround/json5-go/printer"
+)
+
+// Marshaler is implemented by types that encode themselves as a JSON5 value.
+// It takes precedence over json.Marshaler and encoding.TextMarshaler.
+type Marshaler interface {
+	MarshalJSON5() (ast.Node, error)
+}
+
+// Unmarshaler is implemented by types that decode themselves from a JSON5
+// value. UnmarshalJSON5 must copy anything it retains from the node. It takes
+// precedence over json.Unmarshaler and encoding.TextUnmarshaler.
+//
+// As with json.Unmarshaler, null is passed to UnmarshalJSON5 unless the value
+// is reached through a pointer, which is set to nil instead.
+type Unmarshaler interface {
+	UnmarshalJSON5(ast.Node) error
+}
+
+// toJSON renders node as strict JSON for json.Unmarshaler implementations.
+func toJSON(node ast.Node) ([]byte, error) {
+	var b bytes.Buffer
+	if err := writeJSON(&b, node); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+func writeJSON(b *bytes.Buffer, node ast.Node) error {
+	switch n := node.(type) {
+	case *ast.ObjectNode:
+		b.WriteByte('{')
+		for i, m := range n.Members() {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			b.WriteString(printer.Quote(m.Key(), '"'))
+			b.WriteByte(':')
+			if err := writeJSON(b, m.Value()); err != nil {
+				return err
+			}
+		}
+		b.WriteByte('}')
+	case *ast.ArrayNode:
+		b.WriteByte('[')
+		for i, value := range n.Values() {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			if err := writeJSON(b, value); err != nil {
+				return err
+			}
+		}
+		b.WriteByte(']')
+	case *ast.StringNode:
+		b.WriteString(printer.Quote(n.Value(), '"'))
+	case *ast.NumberNode:
+		b.WriteString(jsonNumber(n))
+	case *ast.BooleanNode, *ast.NullNode:
+		b.WriteString(printer.Sprint(n))
+	default:
+		return errors.New("cannot represent " + node.Kind().String() + " in JSON")
+	}
+	return nil
+}
+
+// jsonNumber respells a JSON5 number in JSON syntax, which has no hexadecimal
+// numbers, leading plus signs, or leading or trailing decimal points.
+func jsonNumber(n *ast.NumberNode) string {
+	if n.IsHex() {
+		i, _ := n.BigInt()
+		return i.String()
+	}
+
+	raw := strings.TrimPrefix(n.Raw(), "+")
+	sign := ""
+	if strings.HasPrefix(raw, "-") {
+		sign, raw = "-", raw[1:]
+	}
+	mantissa, exponent, hasExp := strings.Cut(strings.ToLower(raw), "e")
+	intPart, fracPart, _ := strings.Cut(mantissa, ".")
+	if intPart == "" {
+		intPart = "0"
+	}
+
+	s := sign + intPart
+	if fracPart != "" {
+		s += "." + fracPart
+	}
+	if hasExp {
+		s += "e" + exponent
+	}
+	return s
+}
@@ -0,0 +1,177 @@
+package json5
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Roundaround/json5-go/ast"
+)
+
+// size implements Marshaler and Unmarshaler, accepting either a number of
+// bytes or a string such as "4k".
+type size int64
+
+func (s size) MarshalJSON5() (ast.Node, error) {
+	if s%1024 == 0 {
+		return ast.NewString(strconv.FormatInt(int64(s/1024), 10) + "k"), nil
+	}
+	return ast.NewNumber(int64(s)), nil
+}
+
+func (s *size) UnmarshalJSON5(node ast.Node) error {
+	switch n := node.(type) {
+	case *ast.NumberNode:
+		i, err := n.Int64()
+		*s = size(i)
+		return err
+	case *ast.StringNode:
+		i, err := strconv.ParseInt(strings.TrimSuffix(n.Value(), "k"), 10, 64)
+		*s = size(i * 1024)
+		return err
+	}
+	return fmt.Errorf("expected size, got %s", node.Kind())
+}
+
+// celsius implements the encoding/json interfaces, with pointer receivers.
+type celsius float64
+
+func (c *celsius) MarshalJSON() ([]byte, error) {
+	return []byte(fmt.Sprintf(`{"celsius":%g}`, float64(*c))), nil
+}
+
+func (c *celsius) UnmarshalJSON(data []byte) error {
+	_, err := fmt.Sscanf(string(data), `{"celsius":%g}`, (*float64)(c))
+	return err
+}
+
+// level implements the encoding text interfaces, and may be used as a map key.
+type level int
+
+func (l level) MarshalText() ([]byte, error) {
+	return []byte(strings.Repeat("*", int(l))), nil
+}
+
+func (l *level) UnmarshalText(text []byte) error {
+	if strings.Trim(string(text), "*") != "" {
+		return errors.New("invalid level")
+	}
+	*l = level(len(text))
+	return nil
+}
+
+type custom struct {
+	Buffer  size             `json:"buffer"`
+	Limit   *size            `json:"limit"`
+	Temp    celsius          `json:"temp"`
+	Level   level            `json:"level"`
+	Levels  map[level]size   `json:"levels"`
+	Timeout time.Duration    `json:"timeout"`
+	At      time.Time        `json:"at"`
+	Missing *size            `json:"missing"`
+	Values  map[string]level `json:"values,omitempty"`
+}
+
+func TestMarshal_Interfaces(t *testing.T) {
+	limit := size(100)
+	value := custom{
+		Buffer:  4096,
+		Limit:   &limit,
+		Temp:    21.5,
+		Level:   2,
+		Levels:  map[level]size{1: 2048},
+		Timeout: time.Second,
+		At:      time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+
+	data, err := Marshal(value)
+	if err != nil {
+		t.Fatalf("returned unexpected error %v", err)
+	}
+	want := `{"buffer":"4k","limit":100,"temp":{"celsius":21.5},"level":"**","levels":{"*":"2k"},` +
+		`"timeout":1000000000,"at":"2024-01-02T03:04:05Z","missing":null}`
+	if string(data) != want {
+		t.Errorf("expected\n%s\ngot\n%s", want, data)
+	}
+
+	var decoded custom
+	if err := Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("returned unexpected error %v", err)
+	}
+	if decoded.Buffer != 4096 || *decoded.Limit != 100 || decoded.Temp != 21.5 || decoded.Level != 2 ||
+		decoded.Levels[1] != 2048 || decoded.Timeout != time.Second || !decoded.At.Equal(value.At) {
+		t.Errorf("expected round trip of %+v, got %+v", value, decoded)
+	}
+}
+
+func TestUnmarshal_InterfaceErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		want   string
+	}{
+		{"unmarshaler", "{buffer: true}", "json5: buffer at ln 1, col 10: expected size, got Boolean"},
+		{"text", "{level: 'x'}", "json5: level at ln 1, col 9: invalid level"},
+		{"text kind", "{level: 3}", "json5: level at ln 1, col 9: expected String, got Number"},
+		{"json", "{temp: Infinity}", "json5: temp at ln 1, col 8: cannot represent Infinity in JSON"},
+		{"time", "{at: 'yesterday'}", "json5: at at ln 1, col 6: parsing time"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var c custom
+			err := Unmarshal([]byte(tt.source), &c)
+			if err == nil || !strings.HasPrefix(err.Error(), tt.want) {
+				t.Errorf("expected %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+// nullable implements Unmarshaler, recording whether it was given null.
+type nullable struct {
+	set, null bool
+}
+
+func (n *nullable) UnmarshalJSON5(node ast.Node) error {
+	n.set, n.null = true, node.Kind() == ast.NULL
+	return nil
+}
+
+func TestUnmarshal_NullInterfaces(t *testing.T) {
+	v := struct {
+		Value   nullable  `json:"value"`
+		Pointer *nullable `json:"pointer"`
+		Level   level     `json:"level"`
+	}{Pointer: &nullable{}, Level: 2}
+
+	if err := Unmarshal([]byte("{value: null, pointer: null, level: null}"), &v); err != nil {
+		t.Fatalf("returned unexpected error %v", err)
+	}
+	if !v.Value.set || !v.Value.null {
+		t.Errorf("expected null to be passed to UnmarshalJSON5, got %+v", v.Value)
+	}
+	if v.Pointer != nil {
+		t.Errorf("expected pointer to be set to nil, got %+v", v.Pointer)
+	}
+	if v.Level != 2 {
+		t.Errorf("expected null to leave a TextUnmarshaler unchanged, got %d", v.Level)
+	}
+}
+
+func TestToJSON(t *testing.T) {
+	node, err := ast.Parse(`{'a': [+1, .5, 5., -0x1F, 1E3, 'it\'s'], b: null, "c": false}`)
+	if err != nil {
+		t.Fatalf("returned unexpected error %v", err)
+	}
+	data, err := toJSON(node)
+	if err != nil {
+		t.Fatalf("returned unexpected error %v", err)
+	}
+	if want := `{"a":[1,0.5,5,-31,1e3,"it's"],"b":null,"c":false}`; string(data) != want {
+		t.Errorf("expected %s, got %s", want, data)
+	}
+}
@@ -0,0 +1,122 @@
+// Package merge combines JSON5 documents, both as JSON Merge Patch (RFC 7396)
+// and as a configurable deep merge for layered configuration.
+//
+// Merging never modifies its inputs. Every node in the result is a copy of a
+// node from one of the inputs, so its Source, Start and End still describe
+// where it came from. An object present in several layers keeps the position
+// of the first layer that defined it.
+package merge
+
+import (
+	"github.com/Roundaround/json5-go/ast"
+)
+
+type ArrayPolicy int
+
+const (
+	// ReplaceArrays replaces an array with the array from the later layer.
+	ReplaceArrays ArrayPolicy = iota
+	// AppendArrays appends the elements of the later array to the earlier one.
+	AppendArrays
+)
+
+type NullPolicy int
+
+const (
+	// NullDeletes removes a member when a later layer sets it to null.
+	NullDeletes NullPolicy = iota
+	// NullSets sets the member to null like any other value.
+	NullSets
+)
+
+type Option func(*merger)
+
+// Arrays sets how arrays present in several layers are combined. The default
+// is ReplaceArrays.
+func Arrays(policy ArrayPolicy) Option {
+	return func(m *merger) {
+		m.arrays = policy
+	}
+}
+
+// Nulls sets how null members in later layers are treated. The default is
+// NullDeletes.
+func Nulls(policy NullPolicy) Option {
+	return func(m *merger) {
+		m.nulls = policy
+	}
+}
+
+type merger struct {
+	arrays ArrayPolicy
+	nulls  NullPolicy
+}
+
+// MergePatch applies an RFC 7396 merge patch to target and returns the result.
+func MergePatch(target, patch ast.Node) ast.Node {
+	return (&merger{}).merge(target, patch)
+}
+
+// Merge deep merges layers in order, with each layer overriding the ones
+// before it. Objects are merged member by member, and any other value replaces
+// the earlier one. With the default options, each layer is applied to the
+// result so far as a merge patch.
+func Merge(layers []ast.Node, opts ...Option) ast.Node {
+	m := &merger{}
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	var result ast.Node
+	for _, layer := range layers {
+		result = m.merge(result, layer)
+	}
+	return result
+}
+
+func (m *merger) merge(target, patch ast.Node) ast.Node {
+	switch p := patch.(type) {
+	case *ast.ObjectNode:
+		return m.mergeObject(target, p)
+	case *ast.ArrayNode:
+		if t, ok := target.(*ast.ArrayNode); ok && m.arrays == AppendArrays {
+			result := ast.Clone(t).(*ast.ArrayNode)
+			for _, value := range p.Values() {
+				result.Append(ast.Clone(value))
+			}
+			return result
+		}
+	}
+	if patch == nil {
+		if target == nil {
+			return nil
+		}
+		return ast.Clone(target)
+	}
+	return ast.Clone(patch)
+}
+
+func (m *merger) mergeObject(target ast.Node, patch *ast.ObjectNode) *ast.ObjectNode {
+	result, ok := target.(*ast.ObjectNode)
+	if ok {
+		result = ast.Clone(result).(*ast.ObjectNode)
+	} else {
+		// Start from an empty copy of the patch so that the object keeps its
+		// position
+		result = ast.Clone(patch).(*ast.ObjectNode)
+		for _, key := range result.Keys() {
+			result.Delete(key)
+		}
+	}
+
+	for _, key := range patch.Keys() {
+		value, _ := patch.Value(key)
+		if value.Kind() == ast.NULL && m.nulls == NullDeletes {
+			result.Delete(key)
+			continue
+		}
+		existing, _ := result.Value(key)
+		result.Set(key, m.merge(existing, value))
+	}
+	return result
+}
@@ -0,0 +1,177 @@
+package merge
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/Roundaround/json5-go/ast"
+	"github.com/Roundaround/json5-go/path"
+	"github.com/Roundaround/json5-go/printer"
+)
+
+func TestMergePatch(t *testing.T) {
+	// The examples from RFC 7396, Appendix A
+	tests := []struct {
+		target, patch, want string
+	}{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"a":"foo"}`, `"bar"`, `"bar"`},
+		{`{"e":null}`, `{"a":1}`, `{"e":null,"a":1}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.target+" "+tt.patch, func(t *testing.T) {
+			target, patch := parse(t, tt.target, ""), parse(t, tt.patch, "")
+			before := printer.Sprint(target)
+
+			got := printer.Sprint(MergePatch(target, patch))
+			if got != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, got)
+			}
+			if printer.Sprint(target) != before {
+				t.Errorf("expected target to be unchanged")
+			}
+		})
+	}
+}
+
+func TestMerge(t *testing.T) {
+	base := `{list: [1, 2], keep: true, drop: 1}`
+	override := `{list: [3], drop: null, extra: {x: null}}`
+
+	tests := []struct {
+		name string
+		opts []Option
+		want string
+	}{
+		{"defaults", nil, `{"list":[3],"keep":true,"extra":{}}`},
+		{"append", []Option{Arrays(AppendArrays)}, `{"list":[1,2,3],"keep":true,"extra":{}}`},
+		{"null sets", []Option{Nulls(NullSets)}, `{"list":[3],"keep":true,"drop":null,"extra":{"x":null}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Merge([]ast.Node{parse(t, base, ""), parse(t, override, "")}, tt.opts...)
+			if got := printer.Sprint(result); got != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestMerge_NilLayers(t *testing.T) {
+	target := parse(t, `{a: [1]}`, "")
+	result := MergePatch(target, nil)
+	if result == target || !ast.Equal(result, target) {
+		t.Errorf("expected a copy of the target, got %v", result)
+	}
+	result.(*ast.ObjectNode).Delete("a")
+	if render := printer.Sprint(target); render != `{"a":[1]}` {
+		t.Errorf("expected the target to be unchanged, got %s", render)
+	}
+
+	if result := Merge([]ast.Node{nil, nil}); result != nil {
+		t.Errorf("expected nil, got %v", result)
+	}
+}
+
+func TestMerge_Sources(t *testing.T) {
+	layers := []ast.Node{
+		parse(t, "{\n  db: {host: 'localhost', port: 5432},\n  tags: ['a'],\n}", "base.json5"),
+		parse(t, "{\n  db: {host: 'db.prod'},\n}", "prod.json5"),
+		parse(t, "{tags: ['b'], debug: true}", "local.json5"),
+	}
+	result := Merge(layers, Arrays(AppendArrays))
+
+	tests := []struct {
+		path   *path.Path
+		source string
+		pos    string
+	}{
+		{path.Must(), "base.json5", "1:1"},
+		{path.Must("db"), "base.json5", "2:7"},
+		{path.Must("db", "host"), "prod.json5", "2:14"},
+		{path.Must("db", "port"), "base.json5", "2:33"},
+		{path.Must("tags"), "base.json5", "3:9"},
+		{path.Must("tags", 0), "base.json5", "3:10"},
+		{path.Must("tags", 1), "local.json5", "1:9"},
+		{path.Must("debug"), "local.json5", "1:22"},
+	}
+
+	for _, tt := range tests {
+		node, ok := ast.Lookup(result, tt.path)
+		if !ok {
+			t.Errorf("%q: not found", tt.path)
+			continue
+		}
+		if want := tt.source + ":" + tt.pos; node.Source() != tt.source || node.Start().String() != want {
+			t.Errorf("%q: expected %s, got %s", tt.path, want, node.Start())
+		}
+	}
+}
+
+func parse(t *testing.T, source, name string) ast.Node {
+	t.Helper()
+	node, err := ast.Parse(source, ast.SourceName(name))
+	if err != nil {
+		t.Fatalf("returned unexpected error %v", err)
+	}
+	return node
+}
+
+func TestProvenance(t *testing.T) {
+	layers := []ast.Node{
+		parse(t, "{\n  http: {timeout: 30, hosts: ['a']},\n  debug: true,\n}", "base.json5"),
+		parse(t, "{\n  http: {timeout: 60},\n  debug: null,\n}", "prod.json5"),
+		parse(t, "{http: {timeout: 90, hosts: ['b']}}", "local.json5"),
+	}
+
+	tests := []struct {
+		path *path.Path
+		opts []Option
+		want []string
+	}{
+		{path.Must("http", "timeout"), nil, []string{"base.json5:2:19", "prod.json5:2:19", "local.json5:1:18"}},
+		{path.Must("http"), nil, []string{"base.json5:2:9", "prod.json5:2:9", "local.json5:1:8"}},
+		{path.Must("debug"), nil, []string{"base.json5:3:10", "prod.json5:3:10 (deleted)"}},
+		{path.Must("debug"), []Option{Nulls(NullSets)}, []string{"base.json5:3:10", "prod.json5:3:10"}},
+		{path.Must("http", "hosts"), nil, []string{"base.json5:2:30", "local.json5:1:29"}},
+		{path.Must("http", "hosts", 0), nil, []string{"local.json5:1:30"}},
+		{path.Must("http", "hosts", 1), []Option{Arrays(AppendArrays)}, []string{"local.json5:1:30"}},
+		{path.Must("http", "hosts", 1), nil, nil},
+		{path.Must("missing"), nil, nil},
+	}
+
+	for _, tt := range tests {
+		var got []string
+		for _, c := range Provenance(layers, tt.path, tt.opts...) {
+			got = append(got, c.String())
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%q: expected %q, got %q", tt.path, tt.want, got)
+		}
+	}
+
+	// An ancestor replaced by a later layer hides earlier contributions
+	replaced := []ast.Node{
+		parse(t, "{a: {b: 1}}", "one.json5"),
+		parse(t, "{a: 'flat'}", "two.json5"),
+		parse(t, "{a: {b: 3}}", "three.json5"),
+	}
+	chain := Provenance(replaced, path.Must("a", "b"))
+	if len(chain) != 1 || chain[0].Layer != 2 {
+		t.Errorf("expected only the last layer, got %v", chain)
+	}
+}
@@ -0,0 +1,105 @@
+package merge
+
+import (
+	"fmt"
+
+	"github.com/Roundaround/json5-go/ast"
+	"github.com/Roundaround/json5-go/path"
+	"github.com/Roundaround/json5-go/token"
+)
+
+// Contribution is one layer's part in the value at a path: a node that set the
+// value, was merged into it or, when Deleted, removed it with null.
+type Contribution struct {
+	Layer   int
+	Node    ast.Node
+	Deleted bool
+}
+
+// Position returns where the contributing node starts, including the name of
+// its source.
+func (c Contribution) Position() token.Position {
+	return c.Node.Start()
+}
+
+func (c Contribution) String() string {
+	if c.Deleted {
+		return fmt.Sprintf("%s (deleted)", c.Position())
+	}
+	return c.Position().String()
+}
+
+// Provenance explains the value at p in the result of Merge(layers, opts...).
+// It returns every node that set, merged into, overrode or deleted that value,
+// in layer order, so the last contribution is the one in effect. Contributions
+// made before an ancestor of p was replaced are left out, since they no longer
+// have any effect.
+func Provenance(layers []ast.Node, p *path.Path, opts ...Option) []Contribution {
+	m := &merger{}
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	var chain []Contribution
+	for i, layer := range layers {
+		if layer != nil {
+			chain = append(chain, Contribution{Layer: i, Node: layer})
+		}
+	}
+
+	for _, segment := range p.Segments() {
+		if segment.IsIndex() {
+			chain = m.element(chain, segment.Index())
+		} else {
+			chain = m.member(chain, segment.Key())
+		}
+	}
+
+	if m.nulls == NullDeletes {
+		for i := range chain {
+			chain[i].Deleted = chain[i].Node.Kind() == ast.NULL
+		}
+	}
+	return chain
+}
+
+// member follows chain into the member key of the objects that are still in
+// effect: those after the last value that replaced an object outright.
+func (m *merger) member(chain []Contribution, key string) []Contribution {
+	var next []Contribution
+	for _, c := range chain {
+		obj, ok := c.Node.(*ast.ObjectNode)
+		if !ok {
+			next = nil
+			continue
+		}
+		if value, ok := obj.Value(key); ok {
+			next = append(next, Contribution{Layer: c.Layer, Node: value})
+		}
+	}
+	return next
+}
+
+// element follows chain into an array element. With AppendArrays, the arrays
+// still in effect are concatenated; otherwise only the last array counts.
+func (m *merger) element(chain []Contribution, index int) []Contribution {
+	var arrays []Contribution
+	for _, c := range chain {
+		if c.Node.Kind() != ast.ARRAY || m.arrays == ReplaceArrays {
+			arrays = nil
+		}
+		if c.Node.Kind() == ast.ARRAY {
+			arrays = append(arrays, c)
+		}
+	}
+
+	for _, c := range arrays {
+		arr := c.Node.(*ast.ArrayNode)
+		if index < arr.Len() {
+			value, _ := arr.Value(index)
+			return []Contribution{{Layer: c.Layer, Node: value}}
+		}
+		index -= arr.Len()
+	}
+	return nil
+}
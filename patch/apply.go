@@ -0,0 +1,169 @@
+package patch
+
+import (
+	"fmt"
+
+	"github.com/Roundaround/json5-go/ast"
+	"github.com/Roundaround/json5-go/path"
+)
+
+// Apply applies the patch to a copy of root and returns the result, leaving
+// root unchanged. If any operation fails, no result is returned.
+func (p Patch) Apply(root ast.Node) (ast.Node, error) {
+	doc := &document{root: ast.Clone(root)}
+	for i, op := range p {
+		if err := doc.apply(op); err != nil {
+			return nil, &Error{Index: i, Operation: op, err: err}
+		}
+	}
+	return doc.root, nil
+}
+
+type document struct {
+	root ast.Node
+}
+
+func (d *document) apply(op Operation) error {
+	switch op.Op {
+	case ADD:
+		return d.add(op.Path, ast.Clone(op.Value))
+	case REMOVE:
+		_, err := d.remove(op.Path)
+		return err
+	case REPLACE:
+		return d.replace(op.Path, ast.Clone(op.Value))
+	case MOVE:
+		if op.From == op.Path {
+			_, err := d.lookup(op.From)
+			return err
+		}
+		if isPrefix(op.From, op.Path) {
+			return fmt.Errorf("cannot move %q into itself", op.From)
+		}
+		value, err := d.remove(op.From)
+		if err != nil {
+			return err
+		}
+		return d.add(op.Path, value)
+	case COPY:
+		value, err := d.lookup(op.From)
+		if err != nil {
+			return err
+		}
+		return d.add(op.Path, ast.Clone(value))
+	case TEST:
+		value, err := d.lookup(op.Path)
+		if err != nil {
+			return err
+		}
+		if !ast.Equal(value, op.Value) {
+			return ErrTestFailed
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown op %d", op.Op)
+	}
+}
+
+func (d *document) add(pointer string, value ast.Node) error {
+	p, parent, err := d.resolve(pointer)
+	if err != nil {
+		return err
+	}
+	if parent == nil {
+		d.root = value
+		return nil
+	}
+
+	last := p.Peek()
+	switch n := parent.(type) {
+	case *ast.ObjectNode:
+		n.Set(last.Key(), value)
+		return nil
+	case *ast.ArrayNode:
+		return n.Insert(last.Index(), value)
+	}
+	return nil
+}
+
+func (d *document) remove(pointer string) (ast.Node, error) {
+	p, parent, err := d.resolve(pointer)
+	if err != nil {
+		return nil, err
+	}
+	if parent == nil {
+		return nil, fmt.Errorf("cannot remove the root")
+	}
+
+	last := p.Peek()
+	switch n := parent.(type) {
+	case *ast.ObjectNode:
+		value, ok := n.Value(last.Key())
+		if !ok {
+			return nil, fmt.Errorf("%q: %w", pointer, ErrNotFound)
+		}
+		n.Delete(last.Key())
+		return value, nil
+	case *ast.ArrayNode:
+		return n.Remove(last.Index())
+	}
+	return nil, nil
+}
+
+func (d *document) replace(pointer string, value ast.Node) error {
+	p, parent, err := d.resolve(pointer)
+	if err != nil {
+		return err
+	}
+	if parent == nil {
+		d.root = value
+		return nil
+	}
+
+	last := p.Peek()
+	switch n := parent.(type) {
+	case *ast.ObjectNode:
+		if _, ok := n.Value(last.Key()); !ok {
+			return fmt.Errorf("%q: %w", pointer, ErrNotFound)
+		}
+		n.Set(last.Key(), value)
+		return nil
+	case *ast.ArrayNode:
+		_, err := n.Replace(last.Index(), value)
+		return err
+	}
+	return nil
+}
+
+func (d *document) lookup(pointer string) (ast.Node, error) {
+	p, err := Resolve(d.root, pointer)
+	if err != nil {
+		return nil, err
+	}
+	node, ok := ast.Lookup(d.root, p)
+	if !ok {
+		return nil, fmt.Errorf("%q: %w", pointer, ErrNotFound)
+	}
+	return node, nil
+}
+
+// resolve returns the path for a pointer along with the object or array that
+// holds its last segment, which is nil for the root.
+func (d *document) resolve(pointer string) (*path.Path, ast.Node, error) {
+	p, err := Resolve(d.root, pointer)
+	if err != nil {
+		return nil, nil, err
+	}
+	if p.IsEmpty() {
+		return p, nil, nil
+	}
+	parentPath := p.Clone()
+	parentPath.Parent()
+	parent, _ := ast.Lookup(d.root, parentPath)
+	return p, parent, nil
+}
+
+// isPrefix reports whether pointer a is a proper prefix of pointer b.
+func isPrefix(a, b string) bool {
+	return len(b) > len(a) && b[:len(a)] == a && b[len(a)] == '/'
+}
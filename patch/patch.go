@@ -0,0 +1,160 @@
+// Package patch implements JSON Patch (RFC 6902) for JSON5 documents, applied
+// either to an ast tree or directly to source text.
+package patch
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Roundaround/json5-go/ast"
+)
+
+type Op int
+
+const (
+	UNKNOWN Op = iota
+	ADD
+	REMOVE
+	REPLACE
+	MOVE
+	COPY
+	TEST
+)
+
+func (o Op) String() string {
+	switch o {
+	case ADD:
+		return "add"
+	case REMOVE:
+		return "remove"
+	case REPLACE:
+		return "replace"
+	case MOVE:
+		return "move"
+	case COPY:
+		return "copy"
+	case TEST:
+		return "test"
+	default:
+		return "unknown"
+	}
+}
+
+func lookupOp(name string) Op {
+	for op := ADD; op <= TEST; op++ {
+		if op.String() == name {
+			return op
+		}
+	}
+	return UNKNOWN
+}
+
+var (
+	ErrNotFound   = errors.New("path not found")
+	ErrTestFailed = errors.New("test failed")
+)
+
+// Operation is a single patch operation. Path and From are JSON Pointers
+// (RFC 6901). From is used by MOVE and COPY, and Value by ADD, REPLACE and
+// TEST.
+type Operation struct {
+	Op    Op
+	Path  string
+	From  string
+	Value ast.Node
+}
+
+func (o Operation) String() string {
+	switch o.Op {
+	case MOVE, COPY:
+		return fmt.Sprintf("%s %q to %q", o.Op, o.From, o.Path)
+	default:
+		return fmt.Sprintf("%s %q", o.Op, o.Path)
+	}
+}
+
+// Patch is a sequence of operations, applied in order.
+type Patch []Operation
+
+// Error reports an operation that could not be parsed or applied.
+type Error struct {
+	Index     int
+	Operation Operation
+	err       error
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("patch operation %d (%s): %v", e.Index, e.Operation, e.err)
+}
+
+func (e *Error) Unwrap() []error {
+	return []error{e.err}
+}
+
+// Parse reads a JSON Patch document: an array of operation objects. Members
+// other than op, path, from and value are ignored.
+func Parse(source string) (Patch, error) {
+	root, err := ast.Parse(source)
+	if err != nil {
+		return nil, err
+	}
+	arr, ok := root.(*ast.ArrayNode)
+	if !ok {
+		return nil, fmt.Errorf("expected an array of operations, got %s", root.Kind())
+	}
+
+	patch := make(Patch, 0, arr.Len())
+	for i, node := range arr.Values() {
+		op, err := parseOperation(node)
+		if err != nil {
+			return nil, &Error{Index: i, Operation: op, err: fmt.Errorf("%w at ln %d, col %d", err, node.Line(), node.Column())}
+		}
+		patch = append(patch, op)
+	}
+	return patch, nil
+}
+
+func parseOperation(node ast.Node) (Operation, error) {
+	var op Operation
+	obj, ok := node.(*ast.ObjectNode)
+	if !ok {
+		return op, fmt.Errorf("expected an object, got %s", node.Kind())
+	}
+
+	name, err := stringMember(obj, "op")
+	if err != nil {
+		return op, err
+	}
+	if op.Op = lookupOp(name); op.Op == UNKNOWN {
+		return op, fmt.Errorf("unknown op %q", name)
+	}
+	if op.Path, err = stringMember(obj, "path"); err != nil {
+		return op, err
+	}
+
+	switch op.Op {
+	case MOVE, COPY:
+		if op.From, err = stringMember(obj, "from"); err != nil {
+			return op, err
+		}
+	case ADD, REPLACE, TEST:
+		value, ok := obj.Value("value")
+		if !ok {
+			return op, errors.New(`missing member "value"`)
+		}
+		op.Value = ast.Clone(value)
+	}
+	return op, nil
+}
+
+func stringMember(obj *ast.ObjectNode, key string) (string, error) {
+	value, ok := obj.Value(key)
+	if !ok {
+		return "", fmt.Errorf("missing member %q", key)
+	}
+	s, ok := value.(*ast.StringNode)
+	if !ok {
+		return "", fmt.Errorf("expected %q to be a string, got %s", key, value.Kind())
+	}
+	return s.Value(), nil
+}
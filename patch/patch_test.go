@@ -0,0 +1,160 @@
+package patch
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/Roundaround/json5-go/ast"
+	"github.com/Roundaround/json5-go/path"
+	"github.com/Roundaround/json5-go/printer"
+)
+
+func TestParse(t *testing.T) {
+	p, err := Parse(`[
+		{ "op": "test", "path": "/a/b/c", "value": "foo" },
+		{ op: 'remove', path: '/a/b/c' },
+		{ "op": "add", "path": "/a/b/c", "value": [ "foo", "bar" ] },
+		{ "op": "replace", "path": "/a/b/c", "value": 42 },
+		{ "op": "move", "from": "/a/b/c", "path": "/a/b/d" },
+		{ "op": "copy", "from": "/a/b/d", "path": "/a/b/e" },
+	]`)
+	if err != nil {
+		t.Fatalf("returned unexpected error %v", err)
+	}
+
+	ops := make([]Op, 0, len(p))
+	for _, op := range p {
+		ops = append(ops, op.Op)
+	}
+	if want := []Op{TEST, REMOVE, ADD, REPLACE, MOVE, COPY}; !slices.Equal(ops, want) {
+		t.Errorf("expected %v, got %v", want, ops)
+	}
+	if p[4].From != "/a/b/c" || p[4].Path != "/a/b/d" {
+		t.Errorf("unexpected move %s", p[4])
+	}
+	if p[2].Value.Parent() != nil {
+		t.Errorf("expected value to be detached from the patch document")
+	}
+
+	for _, source := range []string{
+		`{}`,
+		`[{ "path": "/a" }]`,
+		`[{ "op": "jump", "path": "/a" }]`,
+		`[{ "op": "add", "path": "/a" }]`,
+		`[{ "op": "move", "path": "/a" }]`,
+		`[{ "op": "remove", "path": 1 }]`,
+	} {
+		if _, err := Parse(source); err == nil {
+			t.Errorf("%s: expected error", source)
+		}
+	}
+}
+
+func TestPointer(t *testing.T) {
+	root, err := ast.Parse(`{"a/b": {"m~n": [1, 2]}, "0": {"1": true}, "": {"": null}}`)
+	if err != nil {
+		t.Fatalf("returned unexpected error %v", err)
+	}
+
+	tests := []struct {
+		pointer string
+		want    *path.Path
+		err     bool
+	}{
+		{"", path.Must(), false},
+		{"/a~1b/m~0n/1", path.Must("a/b", "m~n", 1), false},
+		{"/a~1b/m~0n/-", path.Must("a/b", "m~n", 2), false},
+		{"/0/1", path.Must("0", "1"), false},
+		{"/new", path.Must("new"), false},
+		{"/", path.Must(""), false},
+		{"//", path.Must("", ""), false},
+		{"a", nil, true},
+		{"/a~2b", nil, true},
+		{"/a~1b/m~0n/01", nil, true},
+		{"/a~1b/m~0n/x", nil, true},
+		{"/missing/key", nil, true},
+	}
+
+	for _, tt := range tests {
+		p, err := Resolve(root, tt.pointer)
+		if tt.err {
+			if err == nil {
+				t.Errorf("%q: expected error", tt.pointer)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: returned unexpected error %v", tt.pointer, err)
+			continue
+		}
+		if !p.Equals(tt.want) {
+			t.Errorf("%q: expected %q, got %q", tt.pointer, tt.want, p)
+		}
+		if tt.pointer != "/a~1b/m~0n/-" && Pointer(p) != tt.pointer {
+			t.Errorf("%q: expected round trip, got %q", tt.pointer, Pointer(p))
+		}
+	}
+}
+
+func TestPatch_Apply(t *testing.T) {
+	tests := []struct {
+		name   string
+		doc    string
+		patch  string
+		want   string
+		errIs  error
+		failed bool
+	}{
+		{"add member", `{foo: 'bar'}`, `[{op: 'add', path: '/baz', value: 'qux'}]`, `{"foo":'bar',"baz":'qux'}`, nil, false},
+		{"add element", `{foo: [1, 2]}`, `[{op: 'add', path: '/foo/1', value: 3}]`, `{"foo":[1,3,2]}`, nil, false},
+		{"append", `[1]`, `[{op: 'add', path: '/-', value: {a: null}}]`, `[1,{"a":null}]`, nil, false},
+		{"add replaces", `{a: 1}`, `[{op: 'add', path: '/a', value: 2}]`, `{"a":2}`, nil, false},
+		{"add root", `{a: 1}`, `[{op: 'add', path: '', value: [true]}]`, `[true]`, nil, false},
+		{"remove", `{a: 1, b: [1, 2]}`, `[{op: 'remove', path: '/a'}, {op: 'remove', path: '/b/0'}]`, `{"b":[2]}`, nil, false},
+		{"replace", `{a: 1}`, `[{op: 'replace', path: '/a', value: 'x'}]`, `{"a":'x'}`, nil, false},
+		{"move", `{a: {b: 1}, c: []}`, `[{op: 'move', from: '/a/b', path: '/c/0'}]`, `{"a":{},"c":[1]}`, nil, false},
+		{"copy", `{a: [1]}`, `[{op: 'copy', from: '/a', path: '/b'}, {op: 'add', path: '/b/-', value: 2}]`, `{"a":[1],"b":[1,2]}`, nil, false},
+		{"test", `{a: 0x10}`, `[{op: 'test', path: '/a', value: 16}]`, `{"a":0x10}`, nil, false},
+		{"test fails", `{a: 1}`, `[{op: 'test', path: '/a', value: 2}]`, "", ErrTestFailed, true},
+		{"missing", `{a: 1}`, `[{op: 'remove', path: '/b'}]`, "", ErrNotFound, true},
+		{"missing parent", `{a: 1}`, `[{op: 'add', path: '/b/c', value: 1}]`, "", ErrNotFound, true},
+		{"out of range", `[1]`, `[{op: 'add', path: '/2', value: 1}]`, "", nil, true},
+		{"move into child", `{a: {b: {}}}`, `[{op: 'move', from: '/a', path: '/a/b/c'}]`, "", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root, err := ast.Parse(tt.doc)
+			if err != nil {
+				t.Fatalf("returned unexpected error %v", err)
+			}
+			p, err := Parse(tt.patch)
+			if err != nil {
+				t.Fatalf("returned unexpected error %v", err)
+			}
+
+			before := printer.Sprint(root)
+			result, err := p.Apply(root)
+			if printer.Sprint(root) != before {
+				t.Errorf("expected original document to be unchanged")
+			}
+			if tt.failed {
+				var perr *Error
+				if !errors.As(err, &perr) {
+					t.Fatalf("expected *Error, got %v", err)
+				}
+				if tt.errIs != nil && !errors.Is(err, tt.errIs) {
+					t.Errorf("expected %v, got %v", tt.errIs, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("returned unexpected error %v", err)
+			}
+			if got := printer.Sprint(result); got != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package patch
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Roundaround/json5-go/ast"
+	"github.com/Roundaround/json5-go/path"
+)
+
+// ParsePointer splits a JSON Pointer (RFC 6901) into its unescaped reference
+// tokens. The empty pointer refers to the whole document and has no tokens.
+func ParsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if pointer[0] != '/' {
+		return nil, fmt.Errorf("invalid pointer %q: must start with '/'", pointer)
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		for j := 0; j < len(token); j++ {
+			if token[j] == '~' && (j+1 == len(token) || token[j+1] != '0' && token[j+1] != '1') {
+				return nil, fmt.Errorf("invalid pointer %q: bad escape in %q", pointer, token)
+			}
+		}
+		tokens[i] = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+	}
+	return tokens, nil
+}
+
+// Pointer formats a path as a JSON Pointer.
+func Pointer(p *path.Path) string {
+	var b strings.Builder
+	for _, segment := range p.Segments() {
+		b.WriteByte('/')
+		if segment.IsIndex() {
+			b.WriteString(strconv.Itoa(segment.Index()))
+		} else {
+			b.WriteString(strings.NewReplacer("~", "~0", "/", "~1").Replace(segment.Key()))
+		}
+	}
+	return b.String()
+}
+
+// Resolve converts a JSON Pointer into a path by walking root, since a token
+// such as "0" names a key in an object but an index in an array. Every token
+// but the last must refer to an existing node; the last may name a new key or
+// "-", which resolves to the index just past the end of an array.
+func Resolve(root ast.Node, pointer string) (*path.Path, error) {
+	tokens, err := ParsePointer(pointer)
+	if err != nil {
+		return nil, err
+	}
+
+	p := path.Must()
+	node := root
+	for i, token := range tokens {
+		last := i == len(tokens)-1
+		switch n := node.(type) {
+		case *ast.ObjectNode:
+			p.Key(token)
+			node, _ = n.Value(token)
+		case *ast.ArrayNode:
+			index, err := arrayIndex(n, token)
+			if err != nil {
+				return nil, fmt.Errorf("%q: %w", pointer, err)
+			}
+			p.Index(index)
+			node, _ = n.Value(index)
+		default:
+			return nil, fmt.Errorf("%q: %w", pointer, ErrNotFound)
+		}
+		if node == nil && !last {
+			return nil, fmt.Errorf("%q: %w", pointer, ErrNotFound)
+		}
+	}
+	return p, nil
+}
+
+func arrayIndex(arr *ast.ArrayNode, token string) (int, error) {
+	if token == "-" {
+		return arr.Len(), nil
+	}
+	if token == "" || len(token) > 1 && token[0] == '0' || strings.TrimLeft(token, "0123456789") != "" {
+		return 0, fmt.Errorf("invalid array index %q", token)
+	}
+	index, err := strconv.Atoi(token)
+	if err != nil {
+		return 0, errors.New("array index out of range")
+	}
+	return index, nil
+}
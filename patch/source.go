@@ -0,0 +1,429 @@
+package patch
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/Roundaround/json5-go/ast"
+	"github.com/Roundaround/json5-go/lexer"
+	"github.com/Roundaround/json5-go/printer"
+	"github.com/Roundaround/json5-go/token"
+)
+
+// ApplySource applies the patch directly to JSON5 source. Only the text of the
+// affected values is edited, so the formatting and comments of the rest of the
+// document are kept. New members and elements follow the layout of their
+// neighbors, and moved or copied values keep their original text. Added and
+// replaced values are printed in the document's key and quote style, spread
+// over several lines if their container is.
+func (p Patch) ApplySource(source string) (string, error) {
+	for i, op := range p {
+		var err error
+		if source, err = applySource(source, op); err != nil {
+			return "", &Error{Index: i, Operation: op, err: err}
+		}
+	}
+	return source, nil
+}
+
+func applySource(source string, op Operation) (string, error) {
+	doc, err := parseSource(source)
+	if err != nil {
+		return "", err
+	}
+	// Check the operation against the tree first, so that the edits below
+	// only need to handle valid operations
+	if err := doc.apply(op); err != nil {
+		return "", err
+	}
+	doc, _ = parseSource(source)
+
+	switch op.Op {
+	case ADD:
+		text, err := doc.format(op.Path, op.Value)
+		if err != nil {
+			return "", err
+		}
+		return doc.addText(op.Path, text)
+	case REMOVE:
+		return doc.removeText(op.Path)
+	case REPLACE:
+		text, err := doc.format(op.Path, op.Value)
+		if err != nil {
+			return "", err
+		}
+		node, _ := doc.lookup(op.Path)
+		return doc.edit(edit{node.Offset(), node.End().Offset, text}), nil
+	case MOVE:
+		if op.From == op.Path {
+			return source, nil
+		}
+		node, _ := doc.lookup(op.From)
+		text := doc.text(node)
+		source, _ := doc.removeText(op.From)
+		if doc, err = parseSource(source); err != nil {
+			return "", err
+		}
+		return doc.addText(op.Path, text)
+	case COPY:
+		node, _ := doc.lookup(op.From)
+		return doc.addText(op.Path, doc.text(node))
+	default:
+		return source, nil
+	}
+}
+
+type sourceDocument struct {
+	document
+	source string
+}
+
+func parseSource(source string) (*sourceDocument, error) {
+	root, err := ast.Parse(source)
+	if err != nil {
+		return nil, err
+	}
+	return &sourceDocument{document: document{root: root}, source: source}, nil
+}
+
+type edit struct {
+	start, end int
+	text       string
+}
+
+// edit returns the source with the given edits made. Edits must not overlap.
+func (d *sourceDocument) edit(edits ...edit) string {
+	slices.SortFunc(edits, func(a, b edit) int {
+		return b.start - a.start
+	})
+	source := d.source
+	for _, e := range edits {
+		source = source[:e.start] + e.text + source[e.end:]
+	}
+	return source
+}
+
+func (d *sourceDocument) text(node ast.Node) string {
+	return d.source[node.Offset():node.End().Offset]
+}
+
+func (d *sourceDocument) addText(pointer, text string) (string, error) {
+	p, parent, err := d.resolve(pointer)
+	if err != nil {
+		return "", err
+	}
+	if parent == nil {
+		return d.edit(edit{d.root.Offset(), d.root.End().Offset, text}), nil
+	}
+
+	last := p.Peek()
+	switch n := parent.(type) {
+	case *ast.ObjectNode:
+		if value, ok := n.Value(last.Key()); ok {
+			return d.edit(edit{value.Offset(), value.End().Offset, text}), nil
+		}
+		return d.insert(n, n.Len(), d.key(n, last.Key())+": "+text), nil
+	case *ast.ArrayNode:
+		return d.insert(n, last.Index(), text), nil
+	}
+	return "", fmt.Errorf("%q: %w", pointer, ErrNotFound)
+}
+
+func (d *sourceDocument) removeText(pointer string) (string, error) {
+	p, parent, err := d.resolve(pointer)
+	if err != nil {
+		return "", err
+	}
+
+	last := p.Peek()
+	switch n := parent.(type) {
+	case *ast.ObjectNode:
+		source := d.remove(n, slices.IndexFunc(n.Members(), func(m *ast.Member) bool {
+			return m.Key() == last.Key()
+		}))
+		if !slices.ContainsFunc(n.Duplicates(), func(m *ast.Member) bool {
+			return m.Key() == last.Key()
+		}) {
+			return source, nil
+		}
+		// An earlier occurrence of the key would take the removed member's
+		// place when the source is parsed again, so remove every occurrence
+		// as Apply does
+		doc, err := parseSource(source)
+		if err != nil {
+			return "", err
+		}
+		return doc.removeText(pointer)
+	case *ast.ArrayNode:
+		return d.remove(n, last.Index()), nil
+	}
+	return "", fmt.Errorf("%q: %w", pointer, ErrNotFound)
+}
+
+// key formats a new key in the style of the object's existing keys, or of the
+// first key in the document if the object is empty.
+func (d *sourceDocument) key(obj *ast.ObjectNode, key string) string {
+	quote, unquoted := d.keyStyle(obj)
+	if unquoted && printer.IsIdentifier(key) {
+		return key
+	}
+	return printer.Quote(key, quote)
+}
+
+// keyStyle returns the quote character of the object's existing keys, or of
+// the first key in the document if the object is empty, and whether they are
+// left unquoted.
+func (d *sourceDocument) keyStyle(obj *ast.ObjectNode) (rune, bool) {
+	var members []*ast.Member
+	if obj != nil {
+		members = obj.Members()
+	}
+	if len(members) == 0 {
+		for _, node := range ast.Preorder(d.root) {
+			if n, ok := node.(*ast.ObjectNode); ok && n.Len() > 0 {
+				members = n.Members()
+				break
+			}
+		}
+	}
+	if len(members) == 0 {
+		return '"', false
+	}
+	switch first := rune(d.source[members[0].Offset()]); first {
+	case '"', '\'':
+		return first, false
+	default:
+		return '"', true
+	}
+}
+
+// format prints a value to be added or replaced at pointer in the style of
+// the document. The value is spread over several lines, indented to match,
+// if the container it goes into is.
+func (d *sourceDocument) format(pointer string, value ast.Node) (string, error) {
+	_, parent, err := d.resolve(pointer)
+	if err != nil {
+		return "", err
+	}
+
+	obj, _ := parent.(*ast.ObjectNode)
+	quote, unquoted := d.keyStyle(obj)
+	for _, node := range ast.Preorder(d.root) {
+		if s, ok := node.(*ast.StringNode); ok && (s.Quote() == '"' || s.Quote() == '\'') {
+			quote = s.Quote()
+			break
+		}
+	}
+	opts := []printer.Option{printer.QuoteStyle(quote)}
+	if unquoted {
+		opts = append(opts, printer.UnquotedKeys())
+	}
+	if parent != nil && d.count(parent) > 0 {
+		_, end := d.item(parent, d.count(parent)-1)
+		if _, ok := d.comma(end); ok {
+			opts = append(opts, printer.TrailingCommas())
+		}
+	}
+
+	var base string
+	switch {
+	case parent == nil:
+		if !strings.ContainsAny(d.text(d.root), "\r\n") {
+			return printer.Sprint(value, opts...), nil
+		}
+	case d.count(parent) > 0:
+		start, _ := d.item(parent, 0)
+		if !d.startsLine(start) {
+			return printer.Sprint(value, opts...), nil
+		}
+		base = indentation(d.source, start)
+	default:
+		close := parent.End().Offset - 1
+		if !d.startsLine(close) {
+			return printer.Sprint(value, opts...), nil
+		}
+		base = indentation(d.source, close) + d.indentUnit()
+	}
+
+	newline := "\n"
+	if strings.Contains(d.source, "\r\n") {
+		newline = "\r\n"
+	}
+	text := printer.Sprint(value, append(opts, printer.Indent(d.indentUnit()))...)
+	text = strings.TrimSuffix(text, "\n")
+	return strings.ReplaceAll(text, "\n", newline+base), nil
+}
+
+// indentUnit returns the indentation of the first indented line in the
+// document, taken to be one level.
+func (d *sourceDocument) indentUnit() string {
+	for line := range strings.Lines(d.source) {
+		indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+		if indent != "" && strings.TrimSpace(line) != "" {
+			return indent
+		}
+	}
+	return "  "
+}
+
+// item returns the extent of the i'th member or element of a container,
+// including its leading comments.
+func (d *sourceDocument) item(container ast.Node, i int) (start, end int) {
+	var value ast.Node
+	switch n := container.(type) {
+	case *ast.ObjectNode:
+		m := n.Members()[i]
+		value, start = m.Value(), m.Offset()
+	case *ast.ArrayNode:
+		value = n.Values()[i]
+		start = value.Offset()
+	}
+	for _, c := range value.LeadingComments() {
+		start = min(start, c.Offset())
+	}
+	return start, value.End().Offset
+}
+
+func (d *sourceDocument) count(container ast.Node) int {
+	switch n := container.(type) {
+	case *ast.ObjectNode:
+		return len(n.Members())
+	case *ast.ArrayNode:
+		return n.Len()
+	}
+	return 0
+}
+
+func (d *sourceDocument) remove(container ast.Node, i int) string {
+	start, end := d.item(container, i)
+	cut := end
+	comma, hasComma := d.comma(end)
+	if hasComma {
+		cut = comma
+	}
+
+	// An item on a line of its own takes the whole line with it. A trailing
+	// comma left on the previous item is valid JSON5.
+	if d.startsLine(start) {
+		if next, ok := d.endsLine(cut); ok {
+			return d.edit(edit{lineStart(d.source, start), next, ""})
+		}
+	}
+
+	switch {
+	case hasComma:
+		return d.edit(edit{start, cut + len(d.source[cut:]) - len(strings.TrimLeft(d.source[cut:], " \t")), ""})
+	case i > 0:
+		_, prev := d.item(container, i-1)
+		return d.edit(edit{prev, end, ""})
+	default:
+		return d.edit(edit{start, end, ""})
+	}
+}
+
+func (d *sourceDocument) insert(container ast.Node, i int, text string) string {
+	n := d.count(container)
+	newline := "\n"
+	if strings.Contains(d.source, "\r\n") {
+		newline = "\r\n"
+	}
+
+	if n == 0 {
+		open, close := container.Offset(), container.End().Offset-1
+		if d.startsLine(close) {
+			indent := indentation(d.source, close)
+			unit := "  "
+			if strings.Contains(indent, "\t") {
+				unit = "\t"
+			}
+			return d.edit(edit{lineStart(d.source, close), lineStart(d.source, close), indent + unit + text + "," + newline})
+		}
+		return d.edit(edit{open + 1, open + 1, text})
+	}
+
+	if i < n {
+		start, _ := d.item(container, i)
+		if d.startsLine(start) {
+			at := lineStart(d.source, start)
+			return d.edit(edit{at, at, indentation(d.source, start) + text + "," + newline})
+		}
+		return d.edit(edit{start, start, text + ", "})
+	}
+
+	start, end := d.item(container, n-1)
+	comma, hasComma := d.comma(end)
+	if d.startsLine(start) {
+		after := end
+		if hasComma {
+			after = comma
+		}
+		if next, ok := d.endsLine(after); ok {
+			line := indentation(d.source, start) + text
+			if hasComma {
+				line += ","
+			}
+			edits := []edit{{next, next, line + newline}}
+			if !hasComma {
+				edits = append(edits, edit{end, end, ","})
+			}
+			return d.edit(edits...)
+		}
+	}
+	if hasComma {
+		return d.edit(edit{comma, comma, " " + text + ","})
+	}
+	return d.edit(edit{end, end, ", " + text})
+}
+
+// comma returns the offset just past the comma following offset, skipping
+// whitespace and comments.
+func (d *sourceDocument) comma(offset int) (int, bool) {
+	for tok := range lexer.New(d.source[offset:]).All() {
+		if tok.Kind.IsComment() {
+			continue
+		}
+		if tok.Kind == token.COMMA {
+			return offset + tok.End.Offset, true
+		}
+		break
+	}
+	return 0, false
+}
+
+// startsLine reports whether only spaces and tabs precede offset on its line.
+func (d *sourceDocument) startsLine(offset int) bool {
+	return strings.TrimLeft(d.source[lineStart(d.source, offset):offset], " \t") == ""
+}
+
+// endsLine reports whether only whitespace and comments follow offset on its
+// line, returning the offset of the start of the next line.
+func (d *sourceDocument) endsLine(offset int) (int, bool) {
+	rest := d.source[offset:]
+	for tok := range lexer.New(rest).All() {
+		if tok.Line > 1 {
+			break
+		}
+		if !tok.Kind.IsComment() || tok.End.Line > 1 {
+			return 0, false
+		}
+	}
+	i := strings.IndexAny(rest, "\r\n")
+	if i < 0 {
+		return 0, false
+	}
+	if strings.HasPrefix(rest[i:], "\r\n") {
+		return offset + i + 2, true
+	}
+	return offset + i + 1, true
+}
+
+func lineStart(source string, offset int) int {
+	return strings.LastIndexAny(source[:offset], "\r\n") + 1
+}
+
+func indentation(source string, offset int) string {
+	start := lineStart(source, offset)
+	line := source[start:offset]
+	return line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+}
@@ -0,0 +1,237 @@
+package patch
+
+import (
+	"testing"
+
+	"github.com/Roundaround/json5-go/ast"
+	"github.com/Roundaround/json5-go/printer"
+)
+
+const config = `// Service configuration
+{
+  name: 'web', // display name
+  ports: [
+    80,
+    443,
+  ],
+  tags: ['a', 'b'],
+  limits: {},
+  debug: false
+}
+`
+
+func TestPatch_ApplySource(t *testing.T) {
+	tests := []struct {
+		name  string
+		patch string
+		want  string
+	}{
+		{
+			"replace keeps comments",
+			`[{op: 'replace', path: '/name', value: 'api'}]`,
+			`// Service configuration
+{
+  name: 'api', // display name
+  ports: [
+    80,
+    443,
+  ],
+  tags: ['a', 'b'],
+  limits: {},
+  debug: false
+}
+`,
+		},
+		{
+			"add member on its own line",
+			`[{op: 'add', path: '/timeout', value: 30}]`,
+			`// Service configuration
+{
+  name: 'web', // display name
+  ports: [
+    80,
+    443,
+  ],
+  tags: ['a', 'b'],
+  limits: {},
+  debug: false,
+  timeout: 30
+}
+`,
+		},
+		{
+			"insert and append elements",
+			`[
+				{op: 'add', path: '/ports/1', value: 8080},
+				{op: 'add', path: '/ports/-', value: 8443},
+				{op: 'add', path: '/tags/-', value: 'c'},
+				{op: 'add', path: '/tags/0', value: 'z'},
+			]`,
+			`// Service configuration
+{
+  name: 'web', // display name
+  ports: [
+    80,
+    8080,
+    443,
+    8443,
+  ],
+  tags: ['z', 'a', 'b', 'c'],
+  limits: {},
+  debug: false
+}
+`,
+		},
+		{
+			"add to empty object",
+			`[{op: 'add', path: '/limits/cpu', value: 2}, {op: 'add', path: '/limits/memory-mb', value: 512}]`,
+			`// Service configuration
+{
+  name: 'web', // display name
+  ports: [
+    80,
+    443,
+  ],
+  tags: ['a', 'b'],
+  limits: {cpu: 2, "memory-mb": 512},
+  debug: false
+}
+`,
+		},
+		{
+			"remove lines and elements",
+			`[
+				{op: 'remove', path: '/name'},
+				{op: 'remove', path: '/ports/1'},
+				{op: 'remove', path: '/tags/1'},
+				{op: 'remove', path: '/debug'},
+			]`,
+			`// Service configuration
+{
+  ports: [
+    80,
+  ],
+  tags: ['a'],
+  limits: {},
+}
+`,
+		},
+		{
+			"move and copy keep text",
+			`[
+				{op: 'move', from: '/tags', path: '/limits/tags'},
+				{op: 'copy', from: '/ports/1', path: '/limits/port'},
+			]`,
+			`// Service configuration
+{
+  name: 'web', // display name
+  ports: [
+    80,
+    443,
+  ],
+  limits: {tags: ['a', 'b'], port: 443},
+  debug: false
+}
+`,
+		},
+		{
+			"multi-line values follow the document style",
+			`[
+				{op: 'add', path: '/tls', value: {"cert": "x", "key": "y", "ciphers": ["a"]}},
+				{op: 'replace', path: '/ports/0', value: {"port": 80}},
+				{op: 'replace', path: '/tags', value: {"a": true}},
+			]`,
+			`// Service configuration
+{
+  name: 'web', // display name
+  ports: [
+    {
+      port: 80,
+    },
+    443,
+  ],
+  tags: {
+    a: true
+  },
+  limits: {},
+  debug: false,
+  tls: {
+    cert: 'x',
+    key: 'y',
+    ciphers: [
+      'a'
+    ]
+  }
+}
+`,
+		},
+		{
+			"test",
+			`[{op: 'test', path: '/ports', value: [80, 0x1BB]}]`,
+			config,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := Parse(tt.patch)
+			if err != nil {
+				t.Fatalf("returned unexpected error %v", err)
+			}
+			got, err := p.ApplySource(config)
+			if err != nil {
+				t.Fatalf("returned unexpected error %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("expected:\n%s\ngot:\n%s", tt.want, got)
+			}
+		})
+	}
+
+	p, _ := Parse(`[{op: 'remove', path: '/missing'}]`)
+	if _, err := p.ApplySource(config); err == nil {
+		t.Errorf("expected error for missing path")
+	}
+}
+
+func TestPatch_ApplySource_DuplicateKeys(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		patch  string
+	}{
+		{"remove", `{a: 1, a: 2, b: 3}`, `[{op: 'remove', path: '/a'}]`},
+		{"remove three", `{a: 1, b: 2, a: 3, a: 4}`, `[{op: 'remove', path: '/a'}]`},
+		{"replace", `{a: 1, a: 2}`, `[{op: 'replace', path: '/a', value: 5}]`},
+		{"move", `{a: 1, a: 2}`, `[{op: 'move', from: '/a', path: '/b'}]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := Parse(tt.patch)
+			if err != nil {
+				t.Fatalf("returned unexpected error %v", err)
+			}
+			root, err := ast.Parse(tt.source)
+			if err != nil {
+				t.Fatalf("returned unexpected error %v", err)
+			}
+			want, err := p.Apply(root)
+			if err != nil {
+				t.Fatalf("returned unexpected error %v", err)
+			}
+
+			source, err := p.ApplySource(tt.source)
+			if err != nil {
+				t.Fatalf("returned unexpected error %v", err)
+			}
+			got, err := ast.Parse(source)
+			if err != nil {
+				t.Fatalf("returned unexpected error %v for %q", err, source)
+			}
+			if !ast.Equal(got, want) {
+				t.Errorf("expected %s, got %s (from %q)", printer.Sprint(want), printer.Sprint(got), source)
+			}
+		})
+	}
+}
@@ -12,9 +12,12 @@ type Path struct {
 	segments []Segment
 }
 
+// Segment is a single step in a path: either an object key, which may be
+// empty, or an array index.
 type Segment struct {
 	key   string
 	index int
+	isKey bool
 }
 
 type number interface {
@@ -38,7 +41,7 @@ func New(segments ...any) (*Path, error) {
 		}
 		processed = append(processed, s)
 	}
-	if len(processed) > 0 && processed[0].key == Root {
+	if len(processed) > 0 && processed[0].isKey && processed[0].key == Root {
 		// Don't actually store the root segment
 		processed = processed[1:]
 	}
@@ -66,7 +69,7 @@ func Parse(source string) (*Path, error) {
 		}
 		segments = append(segments, *segment)
 	}
-	if len(segments) > 0 && segments[0].key == Root {
+	if len(segments) > 0 && segments[0].isKey && segments[0].key == Root {
 		// Don't actually store the root segment
 		segments = segments[1:]
 	}
@@ -137,7 +140,7 @@ func (p *Path) String() string {
 
 	var b strings.Builder
 	for i, segment := range p.segments {
-		if segment.key != "" && i > 0 {
+		if segment.isKey && segment.key != "" && i > 0 {
 			b.WriteString(".")
 		}
 		b.WriteString(segment.String())
@@ -153,7 +156,7 @@ func (p *Path) Equals(other *Path) bool {
 }
 
 func Key(key string) Segment {
-	return Segment{key: key}
+	return Segment{key: key, isKey: true}
 }
 
 func Index[N number](index N) Segment {
@@ -206,8 +209,26 @@ func NewSegment(segment any) (Segment, error) {
 }
 
 func (s *Segment) String() string {
-	if s.key != "" {
+	switch {
+	case !s.isKey:
+		return fmt.Sprintf("[%d]", s.index)
+	case s.key == "":
+		return `[""]`
+	default:
 		return s.key
 	}
-	return fmt.Sprintf("[%d]", s.index)
+}
+
+// IsIndex reports whether the segment is an array index rather than an object
+// key.
+func (s *Segment) IsIndex() bool {
+	return !s.isKey
+}
+
+func (s *Segment) Key() string {
+	return s.key
+}
+
+func (s *Segment) Index() int {
+	return s.index
 }
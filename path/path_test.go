@@ -44,19 +44,29 @@ func TestParse(t *testing.T) {
 		successCase("$"), // Root gets trimmed
 		successCase(
 			"foo",
-			Segment{key: "foo"},
+			Key("foo"),
 		),
 		successCase(
 			"[0]",
-			Segment{index: 0},
+			Index(0),
 		),
 		successCase(
 			"['0']",
-			Segment{key: "0"},
+			Key("0"),
 		),
 		successCase(
 			"[\"0\"]",
-			Segment{key: "0"},
+			Key("0"),
+		),
+		successCase(
+			"[\"\"]",
+			Key(""),
+		),
+		successCase(
+			"foo[''][0]",
+			Key("foo"),
+			Key(""),
+			Index(0),
 		),
 		errorCase(
 			"'foo'",
@@ -75,23 +85,23 @@ func TestParse(t *testing.T) {
 		),
 		successCase(
 			"Foo",
-			Segment{key: "Foo"},
+			Key("Foo"),
 		),
 		successCase(
 			"$foo",
-			Segment{key: "$foo"},
+			Key("$foo"),
 		),
 		successCase(
 			"_foo",
-			Segment{key: "_foo"},
+			Key("_foo"),
 		),
 		successCase(
 			"f$o_o$",
-			Segment{key: "f$o_o$"},
+			Key("f$o_o$"),
 		),
 		successCase(
 			"foo9",
-			Segment{key: "foo9"},
+			Key("foo9"),
 		),
 		errorCase(
 			"9foo",
@@ -110,42 +120,42 @@ func TestParse(t *testing.T) {
 		),
 		successCase(
 			"foo.bar",
-			Segment{key: "foo"},
-			Segment{key: "bar"},
+			Key("foo"),
+			Key("bar"),
 		),
 		successCase(
 			"foo.bar[0]",
-			Segment{key: "foo"},
-			Segment{key: "bar"},
-			Segment{index: 0},
+			Key("foo"),
+			Key("bar"),
+			Index(0),
 		),
 		successCase(
 			"foo.bar[3]",
-			Segment{key: "foo"},
-			Segment{key: "bar"},
-			Segment{index: 3},
+			Key("foo"),
+			Key("bar"),
+			Index(3),
 		),
 		successCase(
 			"foo.bar[3].baz",
-			Segment{key: "foo"},
-			Segment{key: "bar"},
-			Segment{index: 3},
-			Segment{key: "baz"},
+			Key("foo"),
+			Key("bar"),
+			Index(3),
+			Key("baz"),
 		),
 		successCase(
 			"foo.bar[3][9].baz",
-			Segment{key: "foo"},
-			Segment{key: "bar"},
-			Segment{index: 3},
-			Segment{index: 9},
-			Segment{key: "baz"},
+			Key("foo"),
+			Key("bar"),
+			Index(3),
+			Index(9),
+			Key("baz"),
 		),
 		successCase(
 			"[0][0][0][0]",
-			Segment{index: 0},
-			Segment{index: 0},
-			Segment{index: 0},
-			Segment{index: 0},
+			Index(0),
+			Index(0),
+			Index(0),
+			Index(0),
 		),
 	}
 
@@ -190,3 +200,37 @@ func TestParse(t *testing.T) {
 		})
 	}
 }
+
+func TestSegment_Accessors(t *testing.T) {
+	key := Key("foo")
+	if key.IsIndex() || key.Key() != "foo" {
+		t.Errorf("expected key segment foo, got %s", key.String())
+	}
+
+	index := Index(3)
+	if !index.IsIndex() || index.Index() != 3 {
+		t.Errorf("expected index segment 3, got %s", index.String())
+	}
+}
+
+func TestSegment_EmptyKey(t *testing.T) {
+	empty := Key("")
+	if empty.IsIndex() || empty.Key() != "" {
+		t.Errorf("expected empty key segment, got %s", empty.String())
+	}
+	if empty == Index(0) {
+		t.Errorf("expected empty key to differ from index 0")
+	}
+
+	p := Must("foo", "", 0)
+	if got := p.String(); got != `foo[""][0]` {
+		t.Errorf("expected %q, got %q", `foo[""][0]`, got)
+	}
+	parsed, err := Parse(p.String())
+	if err != nil {
+		t.Fatalf("returned unexpected error %v", err)
+	}
+	if !parsed.Equals(p) {
+		t.Errorf("expected %q, got %q", p.String(), parsed.String())
+	}
+}
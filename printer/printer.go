@@ -0,0 +1,259 @@
+// Package printer writes ast trees as JSON5 source.
+package printer
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Roundaround/json5-go/ast"
+)
+
+type Option func(*printer)
+
+// Indent prints each member and element on its own line, indented by indent
+// per level, along with any comments attached to the tree. Without it, output
+// is compact and comments are dropped.
+func Indent(indent string) Option {
+	return func(p *printer) {
+		p.indent = indent
+	}
+}
+
+// UnquotedKeys leaves object keys unquoted where they are valid identifiers.
+func UnquotedKeys() Option {
+	return func(p *printer) {
+		p.unquotedKeys = true
+	}
+}
+
+// TrailingCommas follows the last member or element with a comma when
+// indenting.
+func TrailingCommas() Option {
+	return func(p *printer) {
+		p.trailingCommas = true
+	}
+}
+
+// QuoteStyle writes every string and quoted key with quote, a double or
+// single quote, rather than with each string's own quote character.
+func QuoteStyle(quote rune) Option {
+	return func(p *printer) {
+		p.quote = quote
+	}
+}
+
+type printer struct {
+	w              *bufio.Writer
+	indent         string
+	unquotedKeys   bool
+	trailingCommas bool
+	quote          rune
+	depth          int
+}
+
+// Fprint writes node to w.
+func Fprint(w io.Writer, node ast.Node, opts ...Option) error {
+	p := &printer{w: bufio.NewWriter(w)}
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	if p.indent != "" {
+		p.leading(node)
+	}
+	p.value(node)
+	if p.indent != "" {
+		p.trailing(node)
+		p.w.WriteByte('\n')
+	}
+	return p.w.Flush()
+}
+
+// Sprint returns node as a string.
+func Sprint(node ast.Node, opts ...Option) string {
+	var b strings.Builder
+	Fprint(&b, node, opts...)
+	return b.String()
+}
+
+func (p *printer) value(node ast.Node) {
+	switch n := node.(type) {
+	case *ast.ObjectNode:
+		members := n.Members()
+		p.w.WriteByte('{')
+		p.depth++
+		for i, m := range members {
+			p.newline()
+			p.leading(m.Value())
+			p.key(m.Key())
+			p.w.WriteByte(':')
+			if p.indent != "" {
+				p.w.WriteByte(' ')
+			}
+			p.value(m.Value())
+			p.comma(i == len(members)-1)
+			p.trailing(m.Value())
+		}
+		p.dangling(n.DanglingComments())
+		p.depth--
+		if len(members) > 0 || len(n.DanglingComments()) > 0 {
+			p.newline()
+		}
+		p.w.WriteByte('}')
+	case *ast.ArrayNode:
+		values := n.Values()
+		p.w.WriteByte('[')
+		p.depth++
+		for i, value := range values {
+			p.newline()
+			p.leading(value)
+			p.value(value)
+			p.comma(i == len(values)-1)
+			p.trailing(value)
+		}
+		p.dangling(n.DanglingComments())
+		p.depth--
+		if len(values) > 0 || len(n.DanglingComments()) > 0 {
+			p.newline()
+		}
+		p.w.WriteByte(']')
+	case *ast.StringNode:
+		quote := n.Quote()
+		if p.quote != 0 {
+			quote = p.quote
+		} else if quote != '\'' {
+			quote = '"'
+		}
+		p.w.WriteString(Quote(n.Value(), quote))
+	case *ast.NumberNode:
+		p.w.WriteString(n.String())
+	case *ast.BooleanNode:
+		if n.Value() {
+			p.w.WriteString("true")
+		} else {
+			p.w.WriteString("false")
+		}
+	case *ast.NullNode:
+		p.w.WriteString("null")
+	case *ast.InfinityNode:
+		p.w.WriteString(n.String())
+	case *ast.NaNNode:
+		p.w.WriteString(n.String())
+	}
+}
+
+func (p *printer) key(key string) {
+	if p.unquotedKeys && IsIdentifier(key) {
+		p.w.WriteString(key)
+		return
+	}
+	quote := '"'
+	if p.quote != 0 {
+		quote = p.quote
+	}
+	p.w.WriteString(Quote(key, quote))
+}
+
+func (p *printer) comma(last bool) {
+	if !last || p.indent != "" && p.trailingCommas {
+		p.w.WriteByte(',')
+	}
+}
+
+func (p *printer) newline() {
+	if p.indent == "" {
+		return
+	}
+	p.w.WriteByte('\n')
+	for range p.depth {
+		p.w.WriteString(p.indent)
+	}
+}
+
+// leading writes the comments before a node, each on its own line.
+func (p *printer) leading(node ast.Node) {
+	if p.indent == "" {
+		return
+	}
+	for _, c := range node.LeadingComments() {
+		p.w.WriteString(c.String())
+		p.newline()
+	}
+}
+
+// trailing writes the comments after a node on the same line.
+func (p *printer) trailing(node ast.Node) {
+	if p.indent == "" {
+		return
+	}
+	for _, c := range node.TrailingComments() {
+		p.w.WriteByte(' ')
+		p.w.WriteString(c.String())
+	}
+}
+
+func (p *printer) dangling(comments []*ast.CommentNode) {
+	if p.indent == "" {
+		return
+	}
+	for _, c := range comments {
+		p.newline()
+		p.w.WriteString(c.String())
+	}
+}
+
+// Quote returns s as a JSON5 string literal using the given quote character.
+func Quote(s string, quote rune) string {
+	const hex = "0123456789abcdef"
+
+	var b strings.Builder
+	b.Grow(len(s) + 2)
+	b.WriteRune(quote)
+	for _, ch := range s {
+		switch ch {
+		case quote, '\\':
+			b.WriteByte('\\')
+			b.WriteRune(ch)
+		case '\b':
+			b.WriteString(`\b`)
+		case '\f':
+			b.WriteString(`\f`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		default:
+			if ch < 0x20 || ch == 0x7f || ch == '\u2028' || ch == '\u2029' || ch == utf8.RuneError {
+				b.WriteString(`\u`)
+				for shift := 12; shift >= 0; shift -= 4 {
+					b.WriteByte(hex[ch>>shift&0xf])
+				}
+				continue
+			}
+			b.WriteRune(ch)
+		}
+	}
+	b.WriteRune(quote)
+	return b.String()
+}
+
+// IsIdentifier reports whether s can be written as an unquoted key.
+func IsIdentifier(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		ch := s[i]
+		switch {
+		case ch >= 'a' && ch <= 'z', ch >= 'A' && ch <= 'Z', ch == '_', ch == '$':
+		case ch >= '0' && ch <= '9' && i > 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
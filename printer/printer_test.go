@@ -0,0 +1,95 @@
+package printer
+
+import (
+	"testing"
+
+	"github.com/Roundaround/json5-go/ast"
+)
+
+func TestSprint(t *testing.T) {
+	source := `// config
+{
+  name: 'web', // service name
+  "ports": [80, 0x1BB],
+  ratio: -Infinity,
+  empty: {},
+  nested: {
+    // none yet
+  },
+}`
+	root, err := ast.Parse(source)
+	if err != nil {
+		t.Fatalf("returned unexpected error %v", err)
+	}
+
+	tests := []struct {
+		name string
+		opts []Option
+		want string
+	}{
+		{"compact", nil, `{"name":'web',"ports":[80,0x1BB],"ratio":-Infinity,"empty":{},"nested":{}}`},
+		{"indent", []Option{Indent("  "), UnquotedKeys(), TrailingCommas()}, "// config\n{\n" +
+			"  name: 'web', // service name\n" +
+			"  ports: [\n    80,\n    0x1BB,\n  ],\n" +
+			"  ratio: -Infinity,\n" +
+			"  empty: {},\n" +
+			"  nested: {\n    // none yet\n  },\n" +
+			"}\n"},
+		{"indent without trailing commas", []Option{Indent("\t")}, "// config\n{\n" +
+			"\t\"name\": 'web', // service name\n" +
+			"\t\"ports\": [\n\t\t80,\n\t\t0x1BB\n\t],\n" +
+			"\t\"ratio\": -Infinity,\n" +
+			"\t\"empty\": {},\n" +
+			"\t\"nested\": {\n\t\t// none yet\n\t}\n" +
+			"}\n"},
+		{"single quotes", []Option{QuoteStyle('\'')}, `{'name':'web','ports':[80,0x1BB],'ratio':-Infinity,'empty':{},'nested':{}}`},
+		{"double quotes", []Option{QuoteStyle('"'), UnquotedKeys()}, `{name:"web",ports:[80,0x1BB],ratio:-Infinity,empty:{},nested:{}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Sprint(root, tt.opts...)
+			if got != tt.want {
+				t.Errorf("expected:\n%s\ngot:\n%s", tt.want, got)
+			}
+			if _, err := ast.Parse(got); err != nil {
+				t.Errorf("output does not parse: %v", err)
+			}
+		})
+	}
+}
+
+func TestQuote(t *testing.T) {
+	tests := []struct {
+		s     string
+		quote rune
+		want  string
+	}{
+		{"plain", '"', `"plain"`},
+		{`it's "x"`, '\'', `'it\'s "x"'`},
+		{`it's "x"`, '"', `"it's \"x\""`},
+		{"a\\b\n\t\x00\u2028é", '"', `"a\\b\n\t\u0000\u2028é"`},
+	}
+
+	for _, tt := range tests {
+		got := Quote(tt.s, tt.quote)
+		if got != tt.want {
+			t.Errorf("Quote(%q): expected %s, got %s", tt.s, tt.want, got)
+		}
+		node, err := ast.Parse(got)
+		if err != nil {
+			t.Fatalf("returned unexpected error %v", err)
+		}
+		if v := node.(*ast.StringNode).Value(); v != tt.s {
+			t.Errorf("expected %q to round trip, got %q", tt.s, v)
+		}
+	}
+}
+
+func TestIsIdentifier(t *testing.T) {
+	for s, want := range map[string]bool{"a": true, "_$1": true, "1a": false, "": false, "a-b": false, "é": false} {
+		if IsIdentifier(s) != want {
+			t.Errorf("IsIdentifier(%q): expected %v", s, want)
+		}
+	}
+}
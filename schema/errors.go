@@ -0,0 +1,127 @@
+package schema
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Roundaround/json5-go/annotate"
+	"github.com/Roundaround/json5-go/ast"
+	"github.com/Roundaround/json5-go/path"
+)
+
+// Error reports a schema that could not be compiled.
+type Error struct {
+	keyword string
+	node    ast.Node
+	err     error
+}
+
+func (e *Error) Error() string {
+	if e.keyword != "" {
+		return fmt.Sprintf("invalid schema at ln %d, col %d: %s: %v", e.node.Line(), e.node.Column(), e.keyword, e.err)
+	}
+	return fmt.Sprintf("invalid schema at ln %d, col %d: %v", e.node.Line(), e.node.Column(), e.err)
+}
+
+func (e *Error) Unwrap() []error {
+	return []error{e.err}
+}
+
+func (e *Error) Keyword() string {
+	return e.keyword
+}
+
+func (e *Error) Line() int {
+	return e.node.Line()
+}
+
+func (e *Error) Column() int {
+	return e.node.Column()
+}
+
+// Violation is a single way in which a document fails to match a schema.
+type Violation struct {
+	keyword string
+	message string
+	path    *path.Path
+	node    ast.Node
+}
+
+func (v *Violation) Error() string {
+	p := path.Root
+	if !v.path.IsEmpty() {
+		p = v.path.String()
+	}
+	return fmt.Sprintf("%s at ln %d, col %d: %s", p, v.node.Line(), v.node.Column(), v.message)
+}
+
+// Keyword returns the schema keyword that failed, such as "minimum".
+func (v *Violation) Keyword() string {
+	return v.keyword
+}
+
+func (v *Violation) Message() string {
+	return v.message
+}
+
+// Path returns the path to the offending value within the document.
+func (v *Violation) Path() *path.Path {
+	return v.path
+}
+
+// Node returns the offending value.
+func (v *Violation) Node() ast.Node {
+	return v.node
+}
+
+func (v *Violation) Offset() int {
+	return v.node.Offset()
+}
+
+func (v *Violation) Line() int {
+	return v.node.Line()
+}
+
+func (v *Violation) Column() int {
+	return v.node.Column()
+}
+
+// Annotate renders the violation beneath the offending line of source, the
+// text the document was parsed from, with a caret marking the value.
+func (v *Violation) Annotate(source string) string {
+	return fmt.Sprintf("%s:\n%s", v.Error(), annotate.Line(source, v.Offset(), v.message))
+}
+
+// ValidationError holds every violation found in a document.
+type ValidationError struct {
+	violations []*Violation
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, len(e.violations))
+	for i, v := range e.violations {
+		messages[i] = v.Error()
+	}
+	return strings.Join(messages, "\n")
+}
+
+func (e *ValidationError) Unwrap() []error {
+	errs := make([]error, len(e.violations))
+	for i, v := range e.violations {
+		errs[i] = v
+	}
+	return errs
+}
+
+func (e *ValidationError) Violations() []*Violation {
+	return e.violations
+}
+
+// Annotate renders every violation with Violation.Annotate.
+func (e *ValidationError) Annotate(source string) string {
+	annotated := make([]string, len(e.violations))
+	for i, v := range e.violations {
+		annotated[i] = v.Annotate(source)
+	}
+	return strings.Join(annotated, "\n\n")
+}
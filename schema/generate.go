@@ -0,0 +1,332 @@
+package schema
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/Roundaround/json5-go/ast"
+	"github.com/Roundaround/json5-go/internal/fields"
+	"github.com/Roundaround/json5-go/patch"
+	"github.com/Roundaround/json5-go/path"
+)
+
+// Draft is the $schema URI written by Generate.
+const Draft = "https://json-schema.org/draft/2020-12/schema"
+
+// Generate returns a JSON Schema describing the documents that decode into
+// values of type t. Print the result with printer.Indent to get commented
+// JSON5 source.
+//
+// Struct fields are named by their json5 or json tags, following the rules of
+// encoding/json, and are required if they have the required tag option or
+// are neither pointers nor tagged omitempty. Named struct types other than t
+// itself are placed under $defs and referenced with $ref, so recursive types
+// are supported.
+//
+// A jsonschema tag adds keywords to a field's schema, as a comma-separated
+// list of key=value pairs in which commas may be escaped as "\,":
+//
+//	Port int `json:"port" jsonschema:"description=Port to listen on,minimum=1,maximum=65535"`
+//
+// The supported keys are title, description, format, pattern, enum, default,
+// minimum, maximum, exclusiveMinimum, exclusiveMaximum, minLength,
+// maxLength, minItems and maxItems. Enum may be repeated, once per value.
+// Values of enum and default are strings for string fields and JSON5
+// literals otherwise. The bare keys required and optional override whether
+// the field is required. A default tag, the JSON5 literal read by
+// json5.Unmarshal, also sets the default keyword and makes the field optional
+// unless it has the required tag option.
+func Generate(t reflect.Type) (*ast.ObjectNode, error) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	g := &generator{root: t, names: make(map[reflect.Type]string), taken: make(map[string]bool)}
+	root, err := g.schema(t, false)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := ast.NewObject()
+	doc.Set("$schema", ast.NewString(Draft))
+	for _, key := range root.Keys() {
+		value, _ := root.Value(key)
+		doc.Set(key, value)
+	}
+	if len(g.defs) > 0 {
+		defs := ast.NewObject()
+		for _, def := range g.defs {
+			defs.Set(def.name, def.schema)
+			ast.AddLeadingComments(def.schema, ast.Comment("// "+def.typ.String()))
+		}
+		doc.Set("$defs", defs)
+	}
+	ast.AddLeadingComments(doc, ast.Comment("// Generated from "+t.String()+"."))
+	return doc, nil
+}
+
+// For returns the schema for type T, as Generate.
+func For[T any]() (*ast.ObjectNode, error) {
+	return Generate(reflect.TypeFor[T]())
+}
+
+var (
+	timeType          = reflect.TypeFor[time.Time]()
+	textMarshalerType = reflect.TypeFor[encoding.TextMarshaler]()
+)
+
+type definition struct {
+	name   string
+	typ    reflect.Type
+	schema *ast.ObjectNode
+}
+
+type generator struct {
+	root  reflect.Type
+	names map[reflect.Type]string
+	taken map[string]bool
+	defs  []*definition
+}
+
+// schema returns the schema for t. Named struct types are referenced rather
+// than inlined when ref is set.
+func (g *generator) schema(t reflect.Type, ref bool) (*ast.ObjectNode, error) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	s := ast.NewObject()
+	switch {
+	case t == timeType:
+		s.Set("type", ast.NewString("string"))
+		s.Set("format", ast.NewString("date-time"))
+		return s, nil
+	case t.Implements(textMarshalerType) || reflect.PointerTo(t).Implements(textMarshalerType):
+		s.Set("type", ast.NewString("string"))
+		return s, nil
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		s.Set("type", ast.NewString("boolean"))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		s.Set("type", ast.NewString("integer"))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		s.Set("type", ast.NewString("integer"))
+		s.Set("minimum", ast.NewNumber(0))
+	case reflect.Float32, reflect.Float64:
+		s.Set("type", ast.NewString("number"))
+	case reflect.String:
+		s.Set("type", ast.NewString("string"))
+	case reflect.Interface:
+	case reflect.Slice, reflect.Array:
+		if t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 {
+			s.Set("type", ast.NewString("string"))
+			s.Set("contentEncoding", ast.NewString("base64"))
+			break
+		}
+		items, err := g.schema(t.Elem(), true)
+		if err != nil {
+			return nil, err
+		}
+		s.Set("type", ast.NewString("array"))
+		s.Set("items", items)
+		if t.Kind() == reflect.Array {
+			s.Set("minItems", ast.NewNumber(t.Len()))
+			s.Set("maxItems", ast.NewNumber(t.Len()))
+		}
+	case reflect.Map:
+		if !validMapKey(t.Key()) {
+			return nil, fmt.Errorf("unsupported map key type %s", t.Key())
+		}
+		values, err := g.schema(t.Elem(), true)
+		if err != nil {
+			return nil, err
+		}
+		s.Set("type", ast.NewString("object"))
+		s.Set("additionalProperties", values)
+	case reflect.Struct:
+		if t == g.root && ref {
+			s.Set("$ref", ast.NewString("#"))
+			return s, nil
+		}
+		if t.Name() != "" && t != g.root {
+			return g.reference(t)
+		}
+		return g.object(t)
+	default:
+		return nil, fmt.Errorf("unsupported type %s", t)
+	}
+	return s, nil
+}
+
+// reference returns a $ref to the definition of named struct type t, adding
+// the definition if this is the first reference.
+func (g *generator) reference(t reflect.Type) (*ast.ObjectNode, error) {
+	name, ok := g.names[t]
+	if !ok {
+		name = t.Name()
+		if g.taken[name] {
+			name = t.String()
+		}
+		g.names[t] = name
+		g.taken[name] = true
+
+		def := &definition{name: name, typ: t}
+		g.defs = append(g.defs, def)
+		object, err := g.object(t)
+		if err != nil {
+			return nil, err
+		}
+		def.schema = object
+	}
+
+	s := ast.NewObject()
+	s.Set("$ref", ast.NewString("#"+patch.Pointer(path.Must("$defs", name))))
+	return s, nil
+}
+
+func (g *generator) object(t reflect.Type) (*ast.ObjectNode, error) {
+	properties := ast.NewObject()
+	var required []ast.Node
+
+	for _, f := range fields.Of(t) {
+		s, err := g.schema(f.Type, true)
+		if err != nil {
+			return nil, fmt.Errorf("%s.%s: %w", t.String(), f.Name, err)
+		}
+		literal, hasDefault := f.Tag.Lookup("default")
+		isRequired := f.Required || !hasDefault && !f.OmitEmpty && f.Type.Kind() != reflect.Pointer
+
+		if hasDefault {
+			node, err := ast.Parse(literal)
+			if err != nil {
+				return nil, fmt.Errorf("%s.%s: default: %w", t.String(), f.Name, err)
+			}
+			s.Set("default", node)
+		}
+
+		if tag, ok := f.Tag.Lookup("jsonschema"); ok {
+			isRequired, err = applyTag(s, tag, f.Type, isRequired)
+			if err != nil {
+				return nil, fmt.Errorf("%s.%s: %w", t.String(), f.Name, err)
+			}
+		}
+
+		properties.Set(f.Name, s)
+		if isRequired {
+			required = append(required, ast.NewString(f.Name))
+		}
+	}
+
+	s := ast.NewObject()
+	s.Set("type", ast.NewString("object"))
+	s.Set("properties", properties)
+	if len(required) > 0 {
+		s.Set("required", ast.NewArray(required...))
+	}
+	return s, nil
+}
+
+// applyTag adds the keywords from a jsonschema tag to s and returns whether
+// the field is required.
+func applyTag(s *ast.ObjectNode, tag string, t reflect.Type, required bool) (bool, error) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	var enum []ast.Node
+	for _, entry := range splitTag(tag) {
+		key, value, hasValue := strings.Cut(entry, "=")
+		switch key {
+		case "required", "optional":
+			if hasValue {
+				return false, fmt.Errorf("jsonschema: %s does not take a value", key)
+			}
+			required = key == "required"
+		case "title", "description", "format", "pattern":
+			s.Set(key, ast.NewString(value))
+		case "enum", "default":
+			node, err := literal(value, t)
+			if err != nil {
+				return false, fmt.Errorf("jsonschema: %s: %w", key, err)
+			}
+			if key == "enum" {
+				enum = append(enum, node)
+			} else {
+				s.Set(key, node)
+			}
+		case "minimum", "maximum", "exclusiveMinimum", "exclusiveMaximum":
+			number, err := ast.ParseNumber(value)
+			if err != nil {
+				return false, fmt.Errorf("jsonschema: %s: %w", key, err)
+			}
+			s.Set(key, number)
+		case "minLength", "maxLength", "minItems", "maxItems":
+			number, err := ast.ParseNumber(value)
+			if err != nil {
+				return false, fmt.Errorf("jsonschema: %s: %w", key, err)
+			}
+			if n, err := number.Int64(); err != nil || n < 0 {
+				return false, fmt.Errorf("jsonschema: %s: expected non-negative integer, got %s", key, value)
+			}
+			s.Set(key, number)
+		case "":
+		default:
+			return false, fmt.Errorf("jsonschema: unknown key %q", key)
+		}
+	}
+	if len(enum) > 0 {
+		s.Set("enum", ast.NewArray(enum...))
+	}
+	return required, nil
+}
+
+// splitTag splits a jsonschema tag on commas that are not escaped.
+func splitTag(tag string) []string {
+	var entries []string
+	var b strings.Builder
+	for i := 0; i < len(tag); i++ {
+		switch {
+		case tag[i] == '\\' && i+1 < len(tag) && tag[i+1] == ',':
+			b.WriteByte(',')
+			i++
+		case tag[i] == ',':
+			entries = append(entries, b.String())
+			b.Reset()
+		default:
+			b.WriteByte(tag[i])
+		}
+	}
+	return append(entries, b.String())
+}
+
+// literal converts a tag value to a node, taking it verbatim for strings and
+// as a JSON5 literal otherwise.
+func literal(value string, t reflect.Type) (ast.Node, error) {
+	if t.Kind() == reflect.String {
+		return ast.NewString(value), nil
+	}
+	node, err := ast.Parse(value)
+	if err != nil {
+		var perr *ast.ParseError
+		if errors.As(err, &perr) {
+			return nil, errors.Join(perr.Unwrap()...)
+		}
+		return nil, err
+	}
+	return node, nil
+}
+
+func validMapKey(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return true
+	}
+	return t.Implements(textMarshalerType) || reflect.PointerTo(t).Implements(textMarshalerType)
+}
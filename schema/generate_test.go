@@ -0,0 +1,192 @@
+package schema
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Roundaround/json5-go/ast"
+	"github.com/Roundaround/json5-go/path"
+	"github.com/Roundaround/json5-go/printer"
+)
+
+type named struct {
+	Name string `json:"name" jsonschema:"description=Display name"`
+}
+
+type listener struct {
+	Host string `json5:"host,omitempty" default:"'localhost'"`
+	Port uint16 `json:"port" jsonschema:"minimum=1,maximum=65535"`
+}
+
+type tree struct {
+	Children []tree `json:"children,omitempty"`
+}
+
+type service struct {
+	named
+	Listen  listener          `json:"listen"`
+	Backup  *listener         `json:"backup"`
+	Mode    string            `json:"mode" jsonschema:"enum=fast,enum=safe,default=safe"`
+	Labels  map[string]string `json:"labels,omitempty"`
+	Tree    tree              `json:"tree" jsonschema:"optional"`
+	Ratio   float64           `json:"ratio" jsonschema:"description=Between 0 and 1\\, inclusive"`
+	Started time.Time         `json:"started,omitempty"`
+	Self    *service          `json:"self,omitempty"`
+	hidden  int
+	Ignored bool `json:"-"`
+}
+
+func TestGenerate(t *testing.T) {
+	node, err := For[service]()
+	if err != nil {
+		t.Fatalf("returned unexpected error %v", err)
+	}
+
+	if comments := node.LeadingComments(); len(comments) != 1 || comments[0].String() != "// Generated from schema.service." {
+		t.Errorf("expected a generated-from comment, got %v", comments)
+	}
+
+	want := map[string]string{
+		"$schema":            `"https://json-schema.org/draft/2020-12/schema"`,
+		"type":               `"object"`,
+		"properties.name":    `{type:"string",description:"Display name"}`,
+		"properties.listen":  `{$ref:"#/$defs/listener"}`,
+		"properties.backup":  `{$ref:"#/$defs/listener"}`,
+		"properties.mode":    `{type:"string",default:"safe",enum:["fast","safe"]}`,
+		"properties.labels":  `{type:"object",additionalProperties:{type:"string"}}`,
+		"properties.tree":    `{$ref:"#/$defs/tree"}`,
+		"properties.ratio":   `{type:"number",description:"Between 0 and 1, inclusive"}`,
+		"properties.started": `{type:"string",format:"date-time"}`,
+		"properties.self":    `{$ref:"#"}`,
+		"required":           `["name","listen","mode","ratio"]`,
+		"$defs.listener":     `{type:"object",properties:{host:{type:"string",default:'localhost'},port:{type:"integer",minimum:1,maximum:65535}},required:["port"]}`,
+		"$defs.tree":         `{type:"object",properties:{children:{type:"array",items:{$ref:"#/$defs/tree"}}}}`,
+	}
+	keys := 0
+	for key, value := range node.All() {
+		if obj, ok := value.(*ast.ObjectNode); ok && (key == "properties" || key == "$defs") {
+			keys += obj.Len()
+		} else {
+			keys++
+		}
+	}
+	if keys != len(want) {
+		t.Errorf("expected %d entries, got %d", len(want), keys)
+	}
+	for p, expected := range want {
+		parent, key, _ := strings.Cut(p, ".")
+		segments := []any{parent}
+		if key != "" {
+			segments = append(segments, key)
+		}
+		value, ok := ast.Lookup(node, path.Must(segments...))
+		if !ok {
+			t.Errorf("%s: expected entry", p)
+			continue
+		}
+		if got := printer.Sprint(value, printer.UnquotedKeys()); got != expected {
+			t.Errorf("%s: expected %s, got %s", p, expected, got)
+		}
+	}
+
+	def, _ := ast.Lookup(node, path.Must("$defs", "listener"))
+	if comments := def.LeadingComments(); len(comments) != 1 || comments[0].String() != "// schema.listener" {
+		t.Errorf("expected definition to name its type, got %v", comments)
+	}
+
+	s, err := New(node)
+	if err != nil {
+		t.Fatalf("returned unexpected error %v", err)
+	}
+	valid, _ := ast.Parse(`{name: 'api', listen: {port: 80}, mode: 'fast', ratio: 0.5, tree: {children: [{}]}}`)
+	if err := s.Validate(valid); err != nil {
+		t.Errorf("returned unexpected error %v", err)
+	}
+	invalid, _ := ast.Parse(`{name: 'api', listen: {port: 0}, mode: 'slow', ratio: 0.5, self: {}}`)
+	err = s.Validate(invalid)
+	if err == nil {
+		t.Fatalf("expected validation error")
+	}
+	if n := len(err.(*ValidationError).Violations()); n != 6 {
+		t.Errorf("expected 6 violations, got %d: %v", n, err)
+	}
+}
+
+func TestGenerate_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		typ  reflect.Type
+		want string
+	}{
+		{"channel", reflect.TypeFor[struct {
+			C chan int `json:"c"`
+		}](), "c: unsupported type chan int"},
+		{"map key", reflect.TypeFor[map[bool]int](), "unsupported map key type bool"},
+		{"unknown key", reflect.TypeFor[struct {
+			A int `jsonschema:"minimun=1"`
+		}](), `A: jsonschema: unknown key "minimun"`},
+		{"bad enum", reflect.TypeFor[struct {
+			A int `jsonschema:"enum=one"`
+		}](), "A: jsonschema: enum: expected value"},
+		{"bad length", reflect.TypeFor[struct {
+			A string `jsonschema:"minLength=-1"`
+		}](), "A: jsonschema: minLength: expected non-negative integer, got -1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Generate(tt.typ)
+			if err == nil {
+				t.Fatalf("expected error")
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got %q", tt.want, err.Error())
+			}
+		})
+	}
+}
+
+func TestGenerate_DefaultTag(t *testing.T) {
+	type config struct {
+		Port int      `json:"port" default:"8080"`
+		Host string   `json:"host,required" default:"'localhost'"`
+		Tags []string `json:"tags"`
+	}
+
+	node, err := For[config]()
+	if err != nil {
+		t.Fatalf("returned unexpected error %v", err)
+	}
+	required, _ := ast.Lookup(node, path.Must("required"))
+	if got := printer.Sprint(required); got != `["host","tags"]` {
+		t.Errorf(`expected ["host","tags"], got %s`, got)
+	}
+
+	s, err := New(node)
+	if err != nil {
+		t.Fatalf("returned unexpected error %v", err)
+	}
+	doc, _ := ast.Parse(`{host: 'example.com', tags: []}`)
+	if err := s.Validate(doc); err != nil {
+		t.Errorf("returned unexpected error %v", err)
+	}
+}
+
+func TestGenerate_PointerRoot(t *testing.T) {
+	want, err := For[listener]()
+	if err != nil {
+		t.Fatalf("returned unexpected error %v", err)
+	}
+	got, err := For[*listener]()
+	if err != nil {
+		t.Fatalf("returned unexpected error %v", err)
+	}
+	if !ast.Equal(got, want) {
+		t.Errorf("expected %s, got %s", printer.Sprint(want), printer.Sprint(got))
+	}
+	if _, ok := got.Value("$defs"); ok {
+		t.Errorf("expected the root to be inlined, got %s", printer.Sprint(got))
+	}
+}
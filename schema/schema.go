@@ -0,0 +1,298 @@
+// Package schema validates JSON5 documents against a subset of JSON Schema
+// draft 2020-12.
+//
+// The supported keywords are type, enum, const, properties, required,
+// additionalProperties, items, prefixItems, pattern, minLength, maxLength,
+// minimum, maximum, exclusiveMinimum, exclusiveMaximum, minItems, maxItems,
+// minProperties, maxProperties, allOf, anyOf, oneOf, not and $ref, where $ref
+// must be a JSON Pointer fragment within the same document, such as
+// "#/$defs/port". Other keywords are ignored. Patterns use Go's regexp syntax
+// rather than ECMA-262.
+//
+// Infinity and NaN are numbers: Infinity compares beyond every bound, while
+// NaN fails every numeric bound.
+//
+// Generate goes the other way, deriving a schema from a Go type.
+package schema
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/Roundaround/json5-go/ast"
+	"github.com/Roundaround/json5-go/patch"
+)
+
+// Schema is a compiled JSON Schema.
+type Schema struct {
+	root *subschema
+}
+
+// Parse compiles a schema from JSON5 source.
+func Parse(source string, opts ...ast.ParseOption) (*Schema, error) {
+	node, err := ast.Parse(source, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return New(node)
+}
+
+// New compiles a schema from a parsed document.
+func New(node ast.Node) (*Schema, error) {
+	c := &compiler{root: node, compiled: make(map[ast.Node]*subschema)}
+	root, err := c.compile(node)
+	if err != nil {
+		return nil, err
+	}
+	return &Schema{root: root}, nil
+}
+
+var types = []string{"null", "boolean", "object", "array", "number", "string", "integer"}
+
+// subschema is a compiled schema object or boolean schema.
+type subschema struct {
+	node ast.Node
+
+	// always is set for the boolean schemas true and false
+	always *bool
+
+	types    []string
+	enum     []ast.Node
+	constant ast.Node
+
+	properties           map[string]*subschema
+	required             []string
+	additionalProperties *subschema
+	minProperties        *int
+	maxProperties        *int
+
+	items       *subschema
+	prefixItems []*subschema
+	minItems    *int
+	maxItems    *int
+
+	pattern   *regexp.Regexp
+	minLength *int
+	maxLength *int
+
+	minimum          *ast.NumberNode
+	maximum          *ast.NumberNode
+	exclusiveMinimum *ast.NumberNode
+	exclusiveMaximum *ast.NumberNode
+
+	allOf []*subschema
+	anyOf []*subschema
+	oneOf []*subschema
+	not   *subschema
+	ref   *subschema
+}
+
+type compiler struct {
+	root     ast.Node
+	compiled map[ast.Node]*subschema
+}
+
+// compile compiles node, reusing earlier results so that recursive references
+// terminate.
+func (c *compiler) compile(node ast.Node) (*subschema, error) {
+	if s, ok := c.compiled[node]; ok {
+		return s, nil
+	}
+	s := &subschema{node: node}
+	c.compiled[node] = s
+
+	switch n := node.(type) {
+	case *ast.BooleanNode:
+		always := n.Value()
+		s.always = &always
+		return s, nil
+	case *ast.ObjectNode:
+		for key, value := range n.All() {
+			err := c.keyword(s, key, value)
+			if serr, ok := err.(*Error); ok {
+				// Errors from nested schemas already carry their position
+				return nil, serr
+			}
+			if err != nil {
+				return nil, &Error{keyword: key, node: value, err: err}
+			}
+		}
+		return s, nil
+	default:
+		return nil, &Error{node: node, err: fmt.Errorf("expected a schema, got %s", node.Kind())}
+	}
+}
+
+func (c *compiler) keyword(s *subschema, key string, value ast.Node) error {
+	var err error
+	switch key {
+	case "type":
+		s.types, err = typeNames(value)
+	case "enum":
+		arr, ok := value.(*ast.ArrayNode)
+		if !ok {
+			return fmt.Errorf("expected an array, got %s", value.Kind())
+		}
+		s.enum = arr.Values()
+	case "const":
+		s.constant = value
+	case "properties":
+		obj, ok := value.(*ast.ObjectNode)
+		if !ok {
+			return fmt.Errorf("expected an object, got %s", value.Kind())
+		}
+		s.properties = make(map[string]*subschema, obj.Len())
+		for name, property := range obj.All() {
+			if s.properties[name], err = c.compile(property); err != nil {
+				return err
+			}
+		}
+	case "required":
+		s.required, err = stringArray(value)
+	case "additionalProperties":
+		s.additionalProperties, err = c.compile(value)
+	case "minProperties":
+		s.minProperties, err = count(value)
+	case "maxProperties":
+		s.maxProperties, err = count(value)
+	case "items":
+		s.items, err = c.compile(value)
+	case "prefixItems":
+		s.prefixItems, err = c.compileAll(value)
+	case "minItems":
+		s.minItems, err = count(value)
+	case "maxItems":
+		s.maxItems, err = count(value)
+	case "pattern":
+		str, ok := value.(*ast.StringNode)
+		if !ok {
+			return fmt.Errorf("expected a string, got %s", value.Kind())
+		}
+		s.pattern, err = regexp.Compile(str.Value())
+	case "minLength":
+		s.minLength, err = count(value)
+	case "maxLength":
+		s.maxLength, err = count(value)
+	case "minimum":
+		s.minimum, err = bound(value)
+	case "maximum":
+		s.maximum, err = bound(value)
+	case "exclusiveMinimum":
+		s.exclusiveMinimum, err = bound(value)
+	case "exclusiveMaximum":
+		s.exclusiveMaximum, err = bound(value)
+	case "allOf":
+		s.allOf, err = c.compileAll(value)
+	case "anyOf":
+		s.anyOf, err = c.compileAll(value)
+	case "oneOf":
+		s.oneOf, err = c.compileAll(value)
+	case "not":
+		s.not, err = c.compile(value)
+	case "$ref":
+		s.ref, err = c.resolve(value)
+	}
+	return err
+}
+
+func (c *compiler) compileAll(node ast.Node) ([]*subschema, error) {
+	arr, ok := node.(*ast.ArrayNode)
+	if !ok || arr.Len() == 0 {
+		return nil, errors.New("expected a non-empty array of schemas")
+	}
+	schemas := make([]*subschema, 0, arr.Len())
+	for _, value := range arr.Values() {
+		s, err := c.compile(value)
+		if err != nil {
+			return nil, err
+		}
+		schemas = append(schemas, s)
+	}
+	return schemas, nil
+}
+
+// resolve compiles the target of a $ref, which must point within the
+// document.
+func (c *compiler) resolve(node ast.Node) (*subschema, error) {
+	str, ok := node.(*ast.StringNode)
+	if !ok {
+		return nil, fmt.Errorf("expected a string, got %s", node.Kind())
+	}
+	ref, ok := strings.CutPrefix(str.Value(), "#")
+	if !ok {
+		return nil, fmt.Errorf("unsupported reference %q: only references within the document are supported", str.Value())
+	}
+
+	var target ast.Node
+	p, err := patch.Resolve(c.root, ref)
+	if err == nil {
+		target, ok = ast.Lookup(c.root, p)
+	}
+	switch {
+	case errors.Is(err, patch.ErrNotFound) || err == nil && !ok:
+		return nil, fmt.Errorf("unresolved reference %q", str.Value())
+	case err != nil:
+		return nil, fmt.Errorf("invalid reference %q: %w", str.Value(), err)
+	}
+	return c.compile(target)
+}
+
+func typeNames(node ast.Node) ([]string, error) {
+	var names []string
+	if str, ok := node.(*ast.StringNode); ok {
+		names = []string{str.Value()}
+	} else {
+		var err error
+		if names, err = stringArray(node); err != nil {
+			return nil, err
+		}
+	}
+	for _, name := range names {
+		if !slices.Contains(types, name) {
+			return nil, fmt.Errorf("unknown type %q", name)
+		}
+	}
+	return names, nil
+}
+
+func stringArray(node ast.Node) ([]string, error) {
+	arr, ok := node.(*ast.ArrayNode)
+	if !ok {
+		return nil, fmt.Errorf("expected an array of strings, got %s", node.Kind())
+	}
+	values := make([]string, 0, arr.Len())
+	for _, value := range arr.Values() {
+		str, ok := value.(*ast.StringNode)
+		if !ok {
+			return nil, fmt.Errorf("expected an array of strings, found %s", value.Kind())
+		}
+		values = append(values, str.Value())
+	}
+	return values, nil
+}
+
+func count(node ast.Node) (*int, error) {
+	n, ok := node.(*ast.NumberNode)
+	if !ok {
+		return nil, fmt.Errorf("expected a non-negative integer, got %s", node.Kind())
+	}
+	i, err := n.Int()
+	if err != nil || i < 0 {
+		return nil, fmt.Errorf("expected a non-negative integer, got %s", n)
+	}
+	return &i, nil
+}
+
+func bound(node ast.Node) (*ast.NumberNode, error) {
+	n, ok := node.(*ast.NumberNode)
+	if !ok {
+		return nil, fmt.Errorf("expected a number, got %s", node.Kind())
+	}
+	if _, err := n.Rat(); err != nil {
+		return nil, err
+	}
+	return n, nil
+}
@@ -0,0 +1,177 @@
+package schema
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/Roundaround/json5-go/ast"
+)
+
+const serviceSchema = `{
+  $schema: 'https://json-schema.org/draft/2020-12/schema',
+  type: 'object',
+  required: ['name', 'port'],
+  properties: {
+    name: {type: 'string', minLength: 1, pattern: '^[a-z][a-z0-9-]*$'},
+    port: {$ref: '#/$defs/port'},
+    mode: {enum: ['dev', 'prod']},
+    version: {const: 2},
+    replicas: {type: 'integer', minimum: 1, maximum: 10},
+    ratio: {type: 'number', exclusiveMinimum: 0, exclusiveMaximum: 1},
+    tags: {type: 'array', items: {type: 'string'}, minItems: 1, maxItems: 3},
+    pair: {prefixItems: [{type: 'string'}, {type: 'number'}], items: false},
+    backend: {oneOf: [{required: ['url']}, {required: ['socket']}]},
+    owner: {anyOf: [{type: 'string'}, {type: 'null'}]},
+    extra: {not: {type: 'null'}, minProperties: 1, maxProperties: 2},
+    tree: {$ref: '#/$defs/node'},
+  },
+  additionalProperties: false,
+  $defs: {
+    port: {type: 'integer', minimum: 1, maximum: 65535},
+    node: {type: 'object', properties: {children: {type: 'array', items: {$ref: '#/$defs/node'}}}},
+  },
+}`
+
+func TestSchema_Validate(t *testing.T) {
+	s, err := Parse(serviceSchema)
+	if err != nil {
+		t.Fatalf("returned unexpected error %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		document string
+		want     []string
+	}{
+		{"valid", `{
+			name: 'web', port: 0x1F90, mode: 'prod', version: 2.0, replicas: 3, ratio: 0.5,
+			tags: ['a'], pair: ['x', 1], backend: {url: 'x'}, owner: null, extra: {a: 1},
+			tree: {children: [{children: []}]},
+		}`, nil},
+		{"missing", `{}`, []string{
+			"$ at ln 1, col 1: missing required property \"name\"",
+			"$ at ln 1, col 1: missing required property \"port\"",
+		}},
+		{"types", `{name: 1, port: 80.5, replicas: 'many'}`, []string{
+			"name at ln 1, col 8: expected string, got number",
+			"port at ln 1, col 17: expected integer, got number",
+			"replicas at ln 1, col 33: expected integer, got string",
+		}},
+		{"bounds", `{name: 'x', port: 70000, replicas: 0, ratio: 1, tags: []}`, []string{
+			"port at ln 1, col 19: must be at most 65535",
+			"replicas at ln 1, col 36: must be at least 1",
+			"ratio at ln 1, col 46: must be less than 1",
+			"tags at ln 1, col 55: must have at least 1 items, got 0",
+		}},
+		{"strings", `{name: 'Web', port: 1, mode: 'test', version: 3}`, []string{
+			"name at ln 1, col 8: must match the pattern \"^[a-z][a-z0-9-]*$\"",
+			"mode at ln 1, col 30: must be one of the allowed values",
+			"version at ln 1, col 47: must be equal to the constant value",
+		}},
+		{"arrays", `{name: 'x', port: 1, tags: ['a', 2, 'c', 'd'], pair: ['a', 'b', 'c']}`, []string{
+			"tags[1] at ln 1, col 34: expected string, got number",
+			"tags at ln 1, col 28: must have at most 3 items, got 4",
+			"pair[1] at ln 1, col 60: expected number, got string",
+			"pair[2] at ln 1, col 65: no value is allowed here",
+		}},
+		{"combinators", `{name: 'x', port: 1, backend: {url: 1, socket: 2}, owner: 1, extra: null}`, []string{
+			"backend at ln 1, col 31: must match exactly one of the oneOf schemas, matched 2",
+			"owner at ln 1, col 59: must match at least one of the anyOf schemas",
+			"extra at ln 1, col 69: must not match the not schema",
+		}},
+		{"additional", `{name: 'x', port: 1, other: true}`, []string{
+			"other at ln 1, col 29: property \"other\" is not allowed",
+		}},
+		{"recursive", `{name: 'x', port: 1, tree: {children: [{children: [1]}]}}`, []string{
+			"tree.children[0].children[0] at ln 1, col 52: expected object, got number",
+		}},
+		{"non-finite", `{name: 'x', port: Infinity, ratio: NaN}`, []string{
+			"port at ln 1, col 19: expected integer, got number",
+			"port at ln 1, col 19: must be at most 65535",
+			"ratio at ln 1, col 36: must be greater than 0",
+			"ratio at ln 1, col 36: must be less than 1",
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			document, err := ast.Parse(tt.document)
+			if err != nil {
+				t.Fatalf("returned unexpected error %v", err)
+			}
+
+			err = s.Validate(document)
+			if tt.want == nil {
+				if err != nil {
+					t.Fatalf("returned unexpected error %v", err)
+				}
+				return
+			}
+
+			var verr *ValidationError
+			if !errors.As(err, &verr) {
+				t.Fatalf("expected *ValidationError, got %v", err)
+			}
+			got := make([]string, 0, len(verr.Violations()))
+			for _, v := range verr.Violations() {
+				got = append(got, v.Error())
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("expected:\n%q\ngot:\n%q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestViolation_Annotate(t *testing.T) {
+	s, err := Parse(`{properties: {port: {maximum: 65535}}}`)
+	if err != nil {
+		t.Fatalf("returned unexpected error %v", err)
+	}
+
+	source := "{\n  port: 70000,\n}"
+	document, _ := ast.Parse(source)
+	err = s.Validate(document)
+
+	var v *Violation
+	if !errors.As(err, &v) {
+		t.Fatalf("expected *Violation, got %v", err)
+	}
+	if v.Keyword() != "maximum" || v.Path().String() != "port" {
+		t.Errorf("unexpected violation %v", v)
+	}
+	want := "port at ln 2, col 9: must be at most 65535:\n  port: 70000,\n        ^ must be at most 65535"
+	if got := v.Annotate(source); got != want {
+		t.Errorf("expected:\n%s\ngot:\n%s", want, got)
+	}
+}
+
+func TestNew_Errors(t *testing.T) {
+	tests := []struct {
+		schema string
+		want   string
+	}{
+		{`1`, "invalid schema at ln 1, col 1: expected a schema, got Number"},
+		{`{type: 'text'}`, "invalid schema at ln 1, col 8: type: unknown type \"text\""},
+		{`{minLength: -1}`, "invalid schema at ln 1, col 13: minLength: expected a non-negative integer, got -1"},
+		{`{pattern: '('}`, "invalid schema at ln 1, col 11: pattern: error parsing regexp: missing closing ): `(`"},
+		{`{$ref: 'other.json#/a'}`, "invalid schema at ln 1, col 8: $ref: unsupported reference \"other.json#/a\": only references within the document are supported"},
+		{`{$ref: '#/$defs/missing'}`, "invalid schema at ln 1, col 8: $ref: unresolved reference \"#/$defs/missing\""},
+		{`{anyOf: []}`, "invalid schema at ln 1, col 9: anyOf: expected a non-empty array of schemas"},
+		{`{properties: {a: {items: {type: 'text'}}}}`, "invalid schema at ln 1, col 33: type: unknown type \"text\""},
+		{`{anyOf: [{}, 1]}`, "invalid schema at ln 1, col 14: expected a schema, got Number"},
+	}
+
+	for _, tt := range tests {
+		_, err := Parse(tt.schema)
+		var serr *Error
+		if !errors.As(err, &serr) {
+			t.Errorf("%s: expected *Error, got %v", tt.schema, err)
+			continue
+		}
+		if err.Error() != tt.want {
+			t.Errorf("%s: expected %q, got %q", tt.schema, tt.want, err.Error())
+		}
+	}
+}
@@ -0,0 +1,240 @@
+package schema
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Roundaround/json5-go/ast"
+	"github.com/Roundaround/json5-go/path"
+)
+
+// Validate checks node against the schema, returning a *ValidationError
+// listing every violation, or nil if the document is valid.
+func (s *Schema) Validate(node ast.Node) error {
+	v := &validator{path: path.Must()}
+	v.validate(s.root, node)
+	if len(v.violations) == 0 {
+		return nil
+	}
+	return &ValidationError{violations: v.violations}
+}
+
+type validator struct {
+	path       *path.Path
+	violations []*Violation
+}
+
+func (v *validator) report(node ast.Node, keyword, format string, args ...any) {
+	v.violations = append(v.violations, &Violation{
+		keyword: keyword,
+		message: fmt.Sprintf(format, args...),
+		path:    v.path.Clone(),
+		node:    node,
+	})
+}
+
+// matches reports whether node is valid against s, without recording any
+// violations.
+func (v *validator) matches(s *subschema, node ast.Node) bool {
+	sub := &validator{path: v.path.Clone()}
+	sub.validate(s, node)
+	return len(sub.violations) == 0
+}
+
+func (v *validator) validate(s *subschema, node ast.Node) {
+	if s.always != nil {
+		if !*s.always {
+			v.report(node, "false", "no value is allowed here")
+		}
+		return
+	}
+
+	if s.ref != nil {
+		v.validate(s.ref, node)
+	}
+	if len(s.types) > 0 && !slices.ContainsFunc(s.types, func(t string) bool { return hasType(node, t) }) {
+		v.report(node, "type", "expected %s, got %s", strings.Join(s.types, " or "), typeOf(node))
+	}
+	if s.enum != nil && !slices.ContainsFunc(s.enum, func(e ast.Node) bool { return ast.Equal(node, e) }) {
+		v.report(node, "enum", "must be one of the allowed values")
+	}
+	if s.constant != nil && !ast.Equal(node, s.constant) {
+		v.report(node, "const", "must be equal to the constant value")
+	}
+
+	switch n := node.(type) {
+	case *ast.ObjectNode:
+		v.validateObject(s, n)
+	case *ast.ArrayNode:
+		v.validateArray(s, n)
+	case *ast.StringNode:
+		v.validateString(s, n)
+	case *ast.NumberNode, *ast.InfinityNode, *ast.NaNNode:
+		v.validateNumber(s, node)
+	}
+
+	for _, sub := range s.allOf {
+		v.validate(sub, node)
+	}
+	if s.anyOf != nil && !slices.ContainsFunc(s.anyOf, func(sub *subschema) bool { return v.matches(sub, node) }) {
+		v.report(node, "anyOf", "must match at least one of the anyOf schemas")
+	}
+	if s.oneOf != nil {
+		matched := 0
+		for _, sub := range s.oneOf {
+			if v.matches(sub, node) {
+				matched++
+			}
+		}
+		if matched != 1 {
+			v.report(node, "oneOf", "must match exactly one of the oneOf schemas, matched %d", matched)
+		}
+	}
+	if s.not != nil && v.matches(s.not, node) {
+		v.report(node, "not", "must not match the not schema")
+	}
+}
+
+func (v *validator) validateObject(s *subschema, obj *ast.ObjectNode) {
+	for _, name := range s.required {
+		if _, ok := obj.Value(name); !ok {
+			v.report(obj, "required", "missing required property %q", name)
+		}
+	}
+
+	for _, key := range obj.Keys() {
+		value, _ := obj.Value(key)
+		sub, ok := s.properties[key]
+		if !ok {
+			sub = s.additionalProperties
+		}
+		if sub == nil {
+			continue
+		}
+		v.path.Key(key)
+		if !ok && sub.always != nil && !*sub.always {
+			v.report(value, "additionalProperties", "property %q is not allowed", key)
+		} else {
+			v.validate(sub, value)
+		}
+		v.path.Pop()
+	}
+
+	count := len(obj.Keys())
+	if s.minProperties != nil && count < *s.minProperties {
+		v.report(obj, "minProperties", "must have at least %d properties, got %d", *s.minProperties, count)
+	}
+	if s.maxProperties != nil && count > *s.maxProperties {
+		v.report(obj, "maxProperties", "must have at most %d properties, got %d", *s.maxProperties, count)
+	}
+}
+
+func (v *validator) validateArray(s *subschema, arr *ast.ArrayNode) {
+	for i, value := range arr.Values() {
+		sub := s.items
+		if i < len(s.prefixItems) {
+			sub = s.prefixItems[i]
+		}
+		if sub == nil {
+			continue
+		}
+		v.path.Index(i)
+		v.validate(sub, value)
+		v.path.Pop()
+	}
+
+	if s.minItems != nil && arr.Len() < *s.minItems {
+		v.report(arr, "minItems", "must have at least %d items, got %d", *s.minItems, arr.Len())
+	}
+	if s.maxItems != nil && arr.Len() > *s.maxItems {
+		v.report(arr, "maxItems", "must have at most %d items, got %d", *s.maxItems, arr.Len())
+	}
+}
+
+func (v *validator) validateString(s *subschema, str *ast.StringNode) {
+	length := utf8.RuneCountInString(str.Value())
+	if s.minLength != nil && length < *s.minLength {
+		v.report(str, "minLength", "must be at least %d characters long, got %d", *s.minLength, length)
+	}
+	if s.maxLength != nil && length > *s.maxLength {
+		v.report(str, "maxLength", "must be at most %d characters long, got %d", *s.maxLength, length)
+	}
+	if s.pattern != nil && !s.pattern.MatchString(str.Value()) {
+		v.report(str, "pattern", "must match the pattern %q", s.pattern)
+	}
+}
+
+func (v *validator) validateNumber(s *subschema, node ast.Node) {
+	check := func(bound *ast.NumberNode, keyword, relation string, ok func(int) bool) {
+		if bound == nil {
+			return
+		}
+		if c, comparable := compare(node, bound); !comparable || !ok(c) {
+			v.report(node, keyword, "must be %s %s", relation, bound)
+		}
+	}
+	check(s.minimum, "minimum", "at least", func(c int) bool { return c >= 0 })
+	check(s.maximum, "maximum", "at most", func(c int) bool { return c <= 0 })
+	check(s.exclusiveMinimum, "exclusiveMinimum", "greater than", func(c int) bool { return c > 0 })
+	check(s.exclusiveMaximum, "exclusiveMaximum", "less than", func(c int) bool { return c < 0 })
+}
+
+// compare compares a numeric node with a bound. It reports false for NaN,
+// which is not ordered.
+func compare(node ast.Node, bound *ast.NumberNode) (int, bool) {
+	switch n := node.(type) {
+	case *ast.InfinityNode:
+		return n.Sign(), true
+	case *ast.NumberNode:
+		b, _ := bound.Rat()
+		if r, err := n.Rat(); err == nil {
+			return r.Cmp(b), true
+		}
+		// The exponent is too large for an exact comparison
+		f, _ := n.Float64()
+		bf, _ := b.Float64()
+		return compareFloats(f, bf), true
+	default:
+		return 0, false
+	}
+}
+
+func compareFloats(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func typeOf(node ast.Node) string {
+	switch node.Kind() {
+	case ast.OBJECT:
+		return "object"
+	case ast.ARRAY:
+		return "array"
+	case ast.STRING:
+		return "string"
+	case ast.NUMBER, ast.INFINITY, ast.NAN:
+		return "number"
+	case ast.BOOLEAN:
+		return "boolean"
+	case ast.NULL:
+		return "null"
+	default:
+		return node.Kind().String()
+	}
+}
+
+func hasType(node ast.Node, name string) bool {
+	if name == "integer" {
+		n, ok := node.(*ast.NumberNode)
+		return ok && n.IsInteger()
+	}
+	return typeOf(node) == name
+}
@@ -75,18 +75,78 @@ func (k Kind) String() string {
 	}
 }
 
+func (k Kind) IsComment() bool {
+	return k == LINE_COMMENT || k == BLOCK_COMMENT
+}
+
+// Token is a single lexical token. Literal holds the token's value, with
+// escape sequences in quoted strings decoded, while Raw holds the exact source
+// text the token was read from. Source names the document it was read from,
+// if known.
 type Token struct {
 	Kind    Kind
 	Literal string
+	Raw     string
+	Source  string
 	Offset  int
 	Line    int
 	Column  int
+	End     Position
+}
+
+func (t Token) Start() Position {
+	return Position{Source: t.Source, Offset: t.Offset, Line: t.Line, Column: t.Column}
+}
+
+func (t Token) Span() Span {
+	return Span{Start: t.Start(), End: t.End}
 }
 
 func (t Token) String() string {
 	return fmt.Sprintf("%s %q", t.Kind, t.Literal)
 }
 
+// Position is a location in the source. Source is the name of the file or URI
+// it belongs to, if known. Offset is a zero-based byte offset, while Line and
+// Column are one-based.
+type Position struct {
+	Source string
+	Offset int
+	Line   int
+	Column int
+}
+
+// String returns the position as "line:column", prefixed with "source:" when
+// the source is known.
+func (p Position) String() string {
+	if p.Source != "" {
+		return fmt.Sprintf("%s:%d:%d", p.Source, p.Line, p.Column)
+	}
+	return fmt.Sprintf("%d:%d", p.Line, p.Column)
+}
+
+// Span is a range of source text. End is exclusive, pointing just past the
+// final character.
+type Span struct {
+	Start Position
+	End   Position
+}
+
+func (s Span) Len() int {
+	return s.End.Offset - s.Start.Offset
+}
+
+func (s Span) Contains(offset int) bool {
+	return offset >= s.Start.Offset && offset < s.End.Offset
+}
+
+func (s Span) String() string {
+	if s.Start.Source != "" {
+		return fmt.Sprintf("%s:%d:%d-%d:%d", s.Start.Source, s.Start.Line, s.Start.Column, s.End.Line, s.End.Column)
+	}
+	return fmt.Sprintf("%s-%s", s.Start, s.End)
+}
+
 var keywords = map[string]Kind{
 	"true":     BOOLEAN,
 	"false":    BOOLEAN,